@@ -0,0 +1,65 @@
+package log
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func Test_LogAllocations(t *testing.T) {
+	if raceEnabled {
+		t.Skip("sync.Pool drops items at random with the race detector enabled")
+	}
+
+	inits := []struct {
+		name string
+		init func(*Config)
+	}{
+		{"Simple", InitSimpleLogger},
+		{"JSON", InitJSONLogger},
+		{"Logfmt", InitLogfmtLogger},
+	}
+
+	entries := []struct {
+		name  string
+		entry *Entry
+	}{
+		{
+			name: "Typed",
+			entry: With(
+				String("region", "eu"),
+				Int("user_id", 42),
+				Bool("admin", false),
+				Float64("ratio", 0.5),
+			),
+		},
+		{
+			name: "Fields",
+			entry: WithFields(Fields{
+				"region":  "eu",
+				"user_id": 42,
+			}).WithFields(Fields{
+				"admin": false,
+				"start": time.Unix(0, 0),
+			}),
+		},
+	}
+
+	for _, init := range inits {
+		for _, entry := range entries {
+			t.Run(init.name+entry.name, func(t *testing.T) {
+				init.init(&Config{
+					Output: ioutil.Discard,
+				})
+
+				allocs := testing.AllocsPerRun(1000, func() {
+					entry.entry.Info("allocations")
+				})
+
+				if allocs != 0 {
+					t.Errorf("expected no allocations, got %v", allocs)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package log
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// CallerMode controls how the file of the calling function is reported.
+type CallerMode uint8
+
+// Caller modes that can be used for Config.Caller.
+const (
+	// CallerBasename reports only the name of the file e.g. main.go.
+	CallerBasename CallerMode = iota
+	// CallerOff omits the caller from log points entirely.
+	CallerOff
+	// CallerModule reports the path of the file relative to the root of the
+	// module it belongs to e.g. cmd/server/main.go.
+	CallerModule
+	// CallerAbsolute reports the absolute path of the file.
+	CallerAbsolute
+)
+
+var callerModeNames = [...]string{
+	CallerBasename: "basename",
+	CallerOff:      "off",
+	CallerModule:   "module",
+	CallerAbsolute: "absolute",
+}
+
+func (m CallerMode) String() string {
+	if int(m) >= len(callerModeNames) {
+		return fmt.Sprintf("CallerMode(%d)", m)
+	}
+	return callerModeNames[m]
+}
+
+// Set implements flag.Value, see UnmarshalText.
+func (m *CallerMode) Set(s string) error {
+	return m.UnmarshalText([]byte(s))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (m CallerMode) MarshalText() ([]byte, error) {
+	if int(m) >= len(callerModeNames) {
+		return nil, fmt.Errorf("invalid caller mode %d", m)
+	}
+	return []byte(callerModeNames[m]), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. It accepts the names
+// basename, off, module and absolute, case-insensitively.
+func (m *CallerMode) UnmarshalText(text []byte) error {
+	for mode, name := range callerModeNames {
+		if strings.EqualFold(string(text), name) {
+			*m = CallerMode(mode)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid caller mode %q, expected one of basename, off, module or absolute", text)
+}
+
+// frame is a resolved stack frame.
+type frame struct {
+	file     string
+	line     int
+	function string
+	// internal is true if the frame belongs to this package, excluding our tests
+	internal bool
+}
+
+var (
+	ownPkgPath = func() string {
+		pc, _, _, _ := runtime.Caller(0)
+		return packagePath(runtime.FuncForPC(pc).Name())
+	}()
+
+	// frameCache maps program counters to the frames they resolve to. As
+	// call sites are fixed, it only ever grows to the number of call sites
+	// in the program.
+	frameCache sync.Map // map[uintptr][]frame
+)
+
+// lookupFrames resolves pc to the frames it belongs to, multiple if there
+// were functions inlined at pc, innermost first.
+func lookupFrames(pc uintptr) []frame {
+	if frames, ok := frameCache.Load(pc); ok {
+		return frames.([]frame)
+	}
+
+	var frames []frame
+	iter := runtime.CallersFrames([]uintptr{pc})
+	for {
+		f, more := iter.Next()
+		frames = append(frames, frame{
+			file:     f.File,
+			line:     f.Line,
+			function: f.Function,
+			internal: packagePath(f.Function) == ownPkgPath && !strings.HasSuffix(f.File, "_test.go"),
+		})
+		if !more {
+			break
+		}
+	}
+
+	frameCache.Store(pc, frames)
+	return frames
+}
+
+// walkFrames calls f with every frame of the calling goroutine outside of
+// this package, innermost first, until f returns false.
+func walkFrames(f func(frame) bool) {
+	var pcs [16]uintptr
+
+	// only skip runtime.Callers, inlined frames of this package are skipped below
+	skip := 1
+	for {
+		n := runtime.Callers(skip, pcs[:])
+		for _, pc := range pcs[:n] {
+			for _, frame := range lookupFrames(pc) {
+				if !frame.internal && !f(frame) {
+					return
+				}
+			}
+		}
+
+		if n < len(pcs) {
+			return
+		}
+		skip += n
+	}
+}
+
+var (
+	helpersMu sync.Mutex
+	// helpers holds a map[string]struct{} of the names of functions marked
+	// by Helper. It is copied on write so reads don't need to lock.
+	helpers atomic.Value
+)
+
+// Helper marks the calling function as a logging helper. When determining
+// the caller of a log point, the file, line and function of helper functions
+// are skipped, similar to testing.T.Helper. Helper can be called from multiple
+// goroutines and is cheap to call repeatedly.
+func Helper() {
+	var name string
+	walkFrames(func(f frame) bool {
+		name = f.function
+		return false
+	})
+
+	if isHelper(name) {
+		return
+	}
+
+	helpersMu.Lock()
+	defer helpersMu.Unlock()
+
+	current, _ := helpers.Load().(map[string]struct{})
+	next := make(map[string]struct{}, len(current)+1)
+	for k := range current {
+		next[k] = struct{}{}
+	}
+	next[name] = struct{}{}
+	helpers.Store(next)
+}
+
+func isHelper(function string) bool {
+	current, _ := helpers.Load().(map[string]struct{})
+	_, ok := current[function]
+	return ok
+}
+
+// formatCaller formats the file path and function name returned by
+// getFunctionInfo according to the configured caller options.
+func (c *Config) formatCaller(file, funcName string) (string, string) {
+	switch c.Caller {
+	case CallerBasename:
+		file = path.Base(file)
+	case CallerModule:
+		file = moduleRelativePath(file, funcName)
+	}
+
+	if c.FullFunctionName {
+		// strip the import path leaving pkg.(*Type).Method.func1
+		funcName = funcName[strings.LastIndex(funcName, "/")+1:]
+	} else {
+		funcName = funcName[strings.LastIndex(funcName, ".")+1:]
+	}
+	return file, funcName
+}
+
+var (
+	modulePathsOnce sync.Once
+	modulePaths     []string
+	mainPkgPath     string
+)
+
+func loadModulePaths() {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+
+	mainPkgPath = info.Path
+	if info.Main.Path != "" {
+		modulePaths = append(modulePaths, info.Main.Path)
+	}
+	for _, dep := range info.Deps {
+		modulePaths = append(modulePaths, dep.Path)
+	}
+
+	// longest first, so that nested modules take precedence
+	sort.Slice(modulePaths, func(i, j int) bool {
+		return len(modulePaths[i]) > len(modulePaths[j])
+	})
+}
+
+// moduleRelativePath returns the path of file relative to the root of the
+// module that contains the package of funcName. If the module can't be
+// determined, the file name and its parent directory are returned.
+func moduleRelativePath(file, funcName string) string {
+	modulePathsOnce.Do(loadModulePaths)
+
+	pkg := packagePath(funcName)
+	if pkg == "main" && mainPkgPath != "" {
+		pkg = mainPkgPath
+	}
+	// external test packages live in the same directory as the package under test
+	pkg = strings.TrimSuffix(pkg, "_test")
+
+	for _, mod := range modulePaths {
+		if pkg == mod {
+			return path.Base(file)
+		}
+		if strings.HasPrefix(pkg, mod+"/") {
+			return pkg[len(mod)+1:] + "/" + path.Base(file)
+		}
+	}
+
+	dir, base := path.Split(file)
+	return path.Join(path.Base(dir), base)
+}
+
+// packagePath returns the import path of the package a function belongs to,
+// given its fully qualified name as reported by the runtime.
+func packagePath(funcName string) string {
+	pkg := funcName
+	lastSlash := strings.LastIndex(funcName, "/")
+	if dot := strings.Index(funcName[lastSlash+1:], "."); dot >= 0 {
+		pkg = funcName[:lastSlash+1+dot]
+	}
+	// the runtime escapes dots in the last element e.g. gopkg.in/yaml%2ev3
+	if strings.Contains(pkg, "%") {
+		if unescaped, err := url.PathUnescape(pkg); err == nil {
+			pkg = unescaped
+		}
+	}
+	return pkg
+}
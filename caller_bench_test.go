@@ -0,0 +1,54 @@
+package log
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// uncachedFunctionInfo resolves the caller without caching, the way it was
+// done before frames were cached, to compare against.
+func uncachedFunctionInfo() (file string, line int, name string) {
+	pc, _, _, _ := runtime.Caller(0)
+
+	ownPkgName := strings.Join(strings.Split(runtime.FuncForPC(pc).Name(), ".")[:2], ".")
+
+	pkgName := ownPkgName
+
+	callDepth := 1
+	for pkgName == ownPkgName && !strings.HasSuffix(file, "_test.go") {
+		pc, file, line, _ = runtime.Caller(callDepth)
+
+		pkgName = strings.Join(strings.Split(runtime.FuncForPC(pc).Name(), ".")[:2], ".")
+
+		name = runtime.FuncForPC(pc).Name()
+		callDepth++
+	}
+
+	return
+}
+
+func Test_GetFunctionInfo(t *testing.T) {
+	file, line, name := getFunctionInfo(0)
+	_, expectedFile, expectedLine, _ := runtime.Caller(0)
+
+	if file != expectedFile || line != expectedLine-1 || name != "github.com/Strum355/log.Test_GetFunctionInfo" {
+		t.Errorf("unexpected caller %s:%d:%s", file, line, name)
+	}
+}
+
+func Benchmark_GetFunctionInfo(b *testing.B) {
+	b.Run("Cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			getFunctionInfo(0)
+		}
+	})
+
+	b.Run("Uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			uncachedFunctionInfo()
+		}
+	})
+}
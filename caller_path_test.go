@@ -0,0 +1,57 @@
+package log
+
+import "testing"
+
+func Test_ModuleRelativePath(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		funcName string
+		expected string
+	}{
+		{
+			name:     "ModuleRoot",
+			file:     "/src/log/log.go",
+			funcName: "github.com/Strum355/log.Info",
+			expected: "log.go",
+		},
+		{
+			name:     "ExternalTest",
+			file:     "/src/log/caller_test.go",
+			funcName: "github.com/Strum355/log_test.Test_Caller.func1",
+			expected: "caller_test.go",
+		},
+		{
+			name:     "NestedPackage",
+			file:     "/src/log/logpflag/flag.go",
+			funcName: "github.com/Strum355/log/logpflag.(*levelValue).Set",
+			expected: "logpflag/flag.go",
+		},
+		{
+			name:     "DeeplyNestedPackage",
+			file:     "/src/log/cmd/log-pseudonym/main.go",
+			funcName: "github.com/Strum355/log/cmd/log-pseudonym.run",
+			expected: "cmd/log-pseudonym/main.go",
+		},
+		{
+			name:     "DependencyWithDot",
+			file:     "/go/pkg/mod/gopkg.in/yaml.v3@v3.0.1/decode.go",
+			funcName: "gopkg.in/yaml%2ev3.(*parser).parse",
+			expected: "decode.go",
+		},
+		{
+			name:     "UnknownModule",
+			file:     "/src/other/pkg/file.go",
+			funcName: "example.com/other/pkg.F",
+			expected: "pkg/file.go",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := moduleRelativePath(test.file, test.funcName); actual != test.expected {
+				t.Errorf("expected path: '%s'. actual path: '%s'", test.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package log_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/Strum355/log"
+)
+
+type callerType struct{}
+
+func (*callerType) logInfo(msg string) {
+	log.Info(msg)
+}
+
+func logHelper(msg string) {
+	log.Helper()
+	log.Info(msg)
+}
+
+func nestedLogHelper(msg string) {
+	log.Helper()
+	logHelper(msg)
+}
+
+func logSkipWrapper(msg string) {
+	log.WithCallerSkip(1).Info(msg)
+}
+
+func Test_CallerSkip(t *testing.T) {
+	defer b.Reset()
+	log.InitSimpleLogger(&log.Config{
+		Output: b,
+	})
+
+	tests := []struct {
+		name string
+		f    func(string)
+	}{
+		{
+			name: "Helper",
+			f:    logHelper,
+		},
+		{
+			name: "NestedHelper",
+			f:    nestedLogHelper,
+		},
+		{
+			name: "WithCallerSkip",
+			f:    logSkipWrapper,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer b.Reset()
+
+			_, _, line, _ := runtime.Caller(0)
+			test.f("hello")
+
+			expected := fmt.Sprintf(" caller_test.go:%d:func1() ", line+1)
+			if !strings.Contains(b.String(), expected) {
+				t.Errorf("expected '%s' to contain '%s'", b.String(), expected)
+			}
+		})
+	}
+}
+
+func Test_Caller(t *testing.T) {
+	defer b.Reset()
+
+	_, thisFile, _, _ := runtime.Caller(0)
+
+	tests := []struct {
+		name     string
+		conf     log.Config
+		f        func(string)
+		expected string
+	}{
+		{
+			name:     "Basename",
+			conf:     log.Config{},
+			f:        new(callerType).logInfo,
+			expected: " caller_test.go:16:logInfo() ",
+		},
+		{
+			name:     "Module",
+			conf:     log.Config{Caller: log.CallerModule},
+			f:        new(callerType).logInfo,
+			expected: " caller_test.go:16:logInfo() ",
+		},
+		{
+			name:     "Absolute",
+			conf:     log.Config{Caller: log.CallerAbsolute},
+			f:        new(callerType).logInfo,
+			expected: " " + filepath.ToSlash(thisFile) + ":16:logInfo() ",
+		},
+		{
+			name:     "FullMethodName",
+			conf:     log.Config{FullFunctionName: true},
+			f:        new(callerType).logInfo,
+			expected: " caller_test.go:16:log_test.(*callerType).logInfo() ",
+		},
+		{
+			name:     "FullClosureName",
+			conf:     log.Config{FullFunctionName: true},
+			f:        func(msg string) { log.Info(msg) },
+			expected: ":log_test.Test_Caller.func1() ",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer b.Reset()
+			conf := test.conf
+			conf.Output = b
+			log.InitSimpleLogger(&conf)
+
+			test.f("hello")
+
+			if !strings.Contains(b.String(), test.expected) {
+				t.Errorf("expected '%s' to contain '%s'", b.String(), test.expected)
+			}
+		})
+	}
+
+	t.Run("Off", func(t *testing.T) {
+		defer b.Reset()
+		log.InitSimpleLogger(&log.Config{
+			Output:      b,
+			Caller:      log.CallerOff,
+			DisableTime: true,
+		})
+
+		log.Info("hello")
+
+		if b.String() != "[INFO ] hello\n" {
+			t.Errorf("expected no caller, got '%s'", b.String())
+		}
+	})
+}
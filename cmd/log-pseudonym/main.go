@@ -0,0 +1,40 @@
+// Command log-pseudonym computes the pseudonyms log.Pseudonymizer creates for
+// the given values, so that log points of a known identifier can be found.
+//
+// The secret is read from the LOG_PSEUDONYM_SECRET environment variable to
+// keep it out of shell history.
+//
+//	LOG_PSEUDONYM_SECRET=... log-pseudonym -key-id 2020-01 user@example.com
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Strum355/log"
+)
+
+func main() {
+	keyID := flag.String("key-id", "", "key ID the secret is configured with")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [-key-id id] value...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	secret := os.Getenv("LOG_PSEUDONYM_SECRET")
+	if secret == "" {
+		fmt.Fprintln(os.Stderr, "LOG_PSEUDONYM_SECRET must be set")
+		os.Exit(2)
+	}
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	for _, value := range flag.Args() {
+		fmt.Printf("%s\t%s\n", value, log.Pseudonym(*keyID, []byte(secret), value))
+	}
+}
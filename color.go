@@ -0,0 +1,50 @@
+package log
+
+import (
+	"io"
+	"os"
+)
+
+const (
+	colorReset   = "\x1b[0m"
+	colorDim     = "\x1b[2m"
+	colorRed     = "\x1b[31m"
+	colorGreen   = "\x1b[32m"
+	colorYellow  = "\x1b[33m"
+	colorMagenta = "\x1b[35m"
+	colorCyan    = "\x1b[36m"
+)
+
+// useColor reports whether colored output should be written to w. Colors are
+// only used if w is a terminal and the NO_COLOR environment variable is unset,
+// see https://no-color.org.
+func useColor(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return isTerminal(w)
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+func levelColor(level LogLevel) string {
+	switch level {
+	case LogError:
+		return colorRed
+	case LogWarning:
+		return colorYellow
+	case LogInformational:
+		return colorGreen
+	}
+	return colorMagenta
+}
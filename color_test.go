@@ -0,0 +1,66 @@
+package log
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func Test_Color(t *testing.T) {
+	var b strings.Builder
+	InitSimpleLogger(&Config{
+		Output:      &b,
+		Color:       true,
+		ErrorPrefix: "ERR",
+		InfoPrefix:  "INFORMATION",
+	})
+
+	if loadConfig().color {
+		t.Fatal("expected color to be disabled for non-terminal output")
+	}
+
+	c, err := newConfig(&Config{
+		Output:      &b,
+		Color:       true,
+		ErrorPrefix: "ERR",
+		InfoPrefix:  "INFORMATION",
+	}, FormatSimple)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.color = true
+	storeConfig(c)
+
+	WithFields(Fields{"key": "value"}).Error("message")
+
+	out := b.String()
+	if !strings.Contains(out, "["+colorRed+"ERR        "+colorReset+"]") {
+		t.Errorf("expected padded and colored custom prefix: %q", out)
+	}
+
+	if !strings.Contains(out, colorCyan+"key"+colorReset+"='value'") {
+		t.Errorf("expected colored field key: %q", out)
+	}
+
+	if !strings.Contains(out, colorDim+"color_test.go:") {
+		t.Errorf("expected dimmed caller: %q", out)
+	}
+}
+
+func Test_UseColor(t *testing.T) {
+	f, err := ioutil.TempFile("", "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if useColor(f) {
+		t.Error("expected no color for regular file")
+	}
+
+	if useColor(new(strings.Builder)) {
+		t.Error("expected no color for non-file writer")
+	}
+}
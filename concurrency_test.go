@@ -0,0 +1,96 @@
+package log_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Strum355/log"
+)
+
+// Test_Concurrency hammers every logging path and reinitialization from many
+// goroutines, writing to a strings.Builder which isn't safe for concurrent use.
+// Run with -race to detect unsynchronized access.
+func Test_Concurrency(t *testing.T) {
+	var out strings.Builder
+	inits := []func(*log.Config){log.InitSimpleLogger, log.InitJSONLogger, log.InitLogfmtLogger}
+
+	conf := &log.Config{
+		Output:           &out,
+		EnableStackTrace: true,
+		StackTraceLevel:  log.LogError,
+		RedactKeys:       []string{"password"},
+		Pseudonymizer: &log.Pseudonymizer{
+			Keys:   []string{"user"},
+			Secret: []byte("secret"),
+		},
+	}
+	log.InitSimpleLogger(conf)
+
+	base := log.WithFields(log.Fields{"password": "hunter2"})
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+				inits[i%len(inits)](conf)
+			}
+		}
+	}()
+
+	var loggers sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		loggers.Add(1)
+		go func(i int) {
+			defer loggers.Done()
+			for j := 0; j < 200; j++ {
+				e := base.With(log.Int("goroutine", i), log.Int("user", j))
+				switch j % 6 {
+				case 0:
+					e.Debug("debug")
+				case 1:
+					e.Info("info")
+				case 2:
+					e.Warn("warn")
+				case 3:
+					e.WithError(errors.New("failed")).Error("error")
+				case 4:
+					log.WithFields(log.Fields{"n": j}).Info("package")
+				case 5:
+					_ = e.Err("err").Error()
+				}
+			}
+		}(i)
+	}
+
+	loggers.Wait()
+	close(stop)
+	wg.Wait()
+
+	for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "\t"), strings.HasPrefix(line, "time="):
+		case strings.HasPrefix(line, "{"):
+			if !json.Valid([]byte(line)) {
+				t.Errorf("invalid JSON log point: '%s'", line)
+			}
+		default:
+			if !logRegex.MatchString(line) {
+				t.Errorf("corrupted log point: '%s'", line)
+			}
+		}
+
+		if strings.Contains(line, "hunter2") {
+			t.Errorf("expected password to be redacted: '%s'", line)
+		}
+	}
+}
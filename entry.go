@@ -2,10 +2,7 @@ package log
 
 import (
 	"context"
-	"io"
-	"os"
-	"runtime"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/opentracing/opentracing-go"
@@ -15,40 +12,92 @@ type logKey struct{}
 
 var Key logKey = struct{}{}
 
+// Entry holds the fields of a log point. Entries are immutable, every With*
+// method returns a new entry that shares the fields of its parent, so a base
+// entry can safely be shared between goroutines and derived from cheaply.
 type Entry struct {
-	fields Fields
-	span   opentracing.Span
+	logger     *Logger
+	parent     *Entry
+	fields     Fields
+	typed      []Field
+	span       opentracing.Span
+	callerSkip int
+	// caller, if set, is reported as the caller instead of resolving it
+	caller *frame
+	// level, if hasLevel is set, overrides the level of Output
+	level    LogLevel
+	hasLevel bool
 }
 
 var emptyEntry = &Entry{}
 
-func WithError(err error) *Entry {
+// derive returns a new child entry of e.
+func (e *Entry) derive() *Entry {
 	return &Entry{
-		fields: Fields{
-			"error": err,
-		},
+		logger:     e.logger,
+		parent:     e,
+		span:       e.span,
+		callerSkip: e.callerSkip,
+		caller:     e.caller,
+		level:      e.level,
+		hasLevel:   e.hasLevel,
 	}
 }
 
+// WithError adds err to the entry. Any Fields carried by errors in its chain,
+// see WrapError, are added to the entry too.
+func WithError(err error) *Entry {
+	return emptyEntry.WithError(err)
+}
+
+// WithError adds err to the entry. Any Fields carried by errors in its chain,
+// see WrapError, are added to the entry too, overriding existing fields.
 func (e *Entry) WithError(err error) *Entry {
-	return e.WithFields(Fields{
-		"error": err,
-	})
+	fields := e.errorFields(err)
+	if fields == nil {
+		fields = make(Fields, 1)
+	}
+	fields["error"] = err
+	return e.withFields(fields)
 }
 
+// WithContext adds the Fields stored in ctx under Key to the entry. If a
+// level is stored in ctx, see ContextWithLevel, it overrides the configured
+// level for log points of the entry.
 func (e *Entry) WithContext(ctx context.Context) *Entry {
-	if fields, ok := ctx.Value(Key).(*Fields); ok {
-		*e = *e.WithFields(*fields)
+	switch fields := ctx.Value(Key).(type) {
+	case Fields:
+		e = e.WithFields(fields)
+	case *Fields:
+		e = e.WithFields(*fields)
+	}
+
+	if level, ok := LevelFromContext(ctx); ok {
+		e = e.derive()
+		e.level, e.hasLevel = level, true
 	}
 	return e
 }
 
+// WithContext adds the Fields stored in ctx under Key to a new entry.
 func WithContext(ctx context.Context) *Entry {
-	e := new(Entry)
-	if fields, ok := ctx.Value(Key).(Fields); ok {
-		e.fields = fields
-	}
-	return e
+	return emptyEntry.WithContext(ctx)
+}
+
+type levelKey struct{}
+
+// ContextWithLevel returns a copy of ctx that stores level as the minimum
+// level of log points of entries created with WithContext, overriding the
+// configured level. This allows e.g. debug log points to be written for a
+// single request, see DebugMiddleware.
+func ContextWithLevel(ctx context.Context, level LogLevel) context.Context {
+	return context.WithValue(ctx, levelKey{}, level)
+}
+
+// LevelFromContext returns the level stored in ctx by ContextWithLevel.
+func LevelFromContext(ctx context.Context) (LogLevel, bool) {
+	level, ok := ctx.Value(levelKey{}).(LogLevel)
+	return level, ok
 }
 
 /* func WithSpan(span opentracing.Span) *Entry {
@@ -62,15 +111,26 @@ func (e *Entry) WithSpan(span opentracing.Span) *Entry {
 	return e
 } */
 
+// WithCallerSkip skips n additional stack frames when determining the caller
+// of the log point, allowing wrapper functions to report their own caller.
+func WithCallerSkip(n int) *Entry {
+	return emptyEntry.WithCallerSkip(n)
+}
+
+// WithCallerSkip skips n additional stack frames when determining the caller
+// of the log point, allowing wrapper functions to report their own caller.
+func (e *Entry) WithCallerSkip(n int) *Entry {
+	child := e.derive()
+	child.callerSkip += n
+	return child
+}
+
+// Clone returns a copy of the entry.
+//
+// Deprecated: entries are immutable, so they can be shared without cloning.
 func (e *Entry) Clone() *Entry {
-	fields := make(Fields, len(e.fields))
-	for k, v := range e.fields {
-		fields[k] = v
-	}
-	return &Entry{
-		fields: fields,
-		span:   e.span,
-	}
+	clone := *e
+	return &clone
 }
 
 func Debug(msg string) {
@@ -105,60 +165,210 @@ func (e *Entry) Error(msg string) {
 	e.log(LogError, msg)
 }
 
+// logState holds the buffers used while creating a single log point. They are
+// pooled so that logging doesn't allocate in the steady state.
+type logState struct {
+	buf    []byte
+	fields []Field
+}
+
+// maxPooledBuffer is the largest buffer capacity kept in a pool, so that a
+// single huge log point doesn't keep its memory alive forever.
+const maxPooledBuffer = 64 << 10
+
+var logStatePool = sync.Pool{
+	New: func() interface{} {
+		return &logState{
+			buf:    make([]byte, 0, 1024),
+			fields: make([]Field, 0, 8),
+		}
+	},
+}
+
+// config returns the configuration the entry logs with, which is nil if it
+// belongs to the global logger and that isn't initialized yet.
+func (e *Entry) config() *Config {
+	if e.logger != nil {
+		return e.logger.config.Load()
+	}
+	return loadConfig()
+}
+
 func (e *Entry) log(level LogLevel, format string) {
-	if level < config.LogLevel {
+	for {
+		c := e.config()
+		if c == nil {
+			if c = e.logPreInit(level, format); c == nil {
+				return
+			}
+		}
+		// retried if c was retired after it was loaded
+		if c.logActive(e, level, format) {
+			return
+		}
+	}
+}
+
+// logActive writes the log point with c unless c was retired, see enter.
+func (c *Config) logActive(e *Entry, level LogLevel, format string) bool {
+	if !c.enter() {
+		return false
+	}
+	defer c.exit()
+	c.log(e, level, format)
+	return true
+}
+
+func (c *Config) log(e *Entry, level LogLevel, format string) {
+	if level < c.minLevel && !e.hasLevel {
 		return
 	}
 
 	now := time.Now()
 
-	builder := new(strings.Builder)
+	var (
+		file, funcName string
+		fileLine       int
+	)
+	if c.Caller != CallerOff || len(c.packageLevels) > 0 || c.filter != nil {
+		file, fileLine, funcName = e.resolveCaller()
+	}
+
+	sinks := c.writesSinks(level)
+	if level < c.outputLevel(e, funcName) && c.filter == nil && !sinks {
+		return
+	}
 
-	file, fileLine, funcName := getFunctionInfo()
+	state := logStatePool.Get().(*logState)
 
-	config.logger.createLogPoint(logPoint{builder, level, fileLine, file, funcName, format, e.fields, now})
+	log := logPoint{
+		level:    level,
+		fileLine: fileLine,
+		file:     file,
+		funcName: funcName,
+		msg:      format,
+		fields:   sortFields(e.appendFields(state.fields[:0])),
+		time:     now,
+	}
+
+	output := c.writesOutput(e, log)
+	if !output && !sinks || !c.sampler.sample(level, format, now) {
+		state.release(state.buf, log.fields)
+		return
+	}
 
-	if level == LogError && config.UseStdErr {
-		io.WriteString(os.Stderr, builder.String())
-	} else {
-		io.WriteString(config.Output, builder.String())
+	if c.EnableStackTrace && level >= c.StackTraceLevel {
+		if log.stack = errorStack(log.fields); log.stack == nil {
+			log.stack = captureStack(e.callerSkip)
+		}
 	}
+
+	c.emit(state, output, log)
 }
 
-func getPrefix(level LogLevel) string {
-	if level == LogError {
-		return config.ErrorPrefix
-	} else if level == LogWarning {
-		return config.WarnPrefix
-	} else if level == LogInformational {
-		return config.InfoPrefix
+// emit formats and writes a log point, whose caller hasn't been formatted
+// and whose fields haven't been processed yet, to the sinks and, if output is
+// set, to Output. The state is returned to the pool afterwards.
+func (c *Config) emit(state *logState, output bool, log logPoint) {
+	if c.Caller != CallerOff {
+		log.file, log.funcName = c.formatCaller(log.file, log.funcName)
 	}
-	return config.DebugPrefix
+
+	c.processFields(log.fields)
+
+	buf := state.buf[:0]
+	if output {
+		buf = c.logger.appendLogPoint(buf, c, log)
+		c.write(log.level, buf)
+	}
+	for _, sink := range c.sinks {
+		if log.level >= sink.LogLevel {
+			buf = sink.logger.appendLogPoint(buf[:0], sink, log)
+			sink.write(log.level, buf)
+		}
+	}
+
+	state.release(buf, log.fields)
 }
 
-func getFunctionInfo() (file string, line int, name string) {
-	pc, _, _, _ := runtime.Caller(0)
+// release returns the state to the pool with the buffers used for a log
+// point, unless they grew too large.
+func (state *logState) release(buf []byte, fields []Field) {
+	if cap(buf) > maxPooledBuffer {
+		return
+	}
 
-	// get package name and filename
-	ownPkgName := strings.Join(strings.Split(runtime.FuncForPC(pc).Name(), ".")[:2], ".")
+	// clear the fields so the pool doesn't keep their values alive
+	fields = fields[:cap(fields)]
+	for i := range fields {
+		fields[i] = Field{}
+	}
+	state.buf, state.fields = buf, fields[:0]
+	logStatePool.Put(state)
+}
 
-	pkgName := ownPkgName
+// flatFields returns the fields of the entry and its ancestors sorted by key.
+// Fields added later take precedence over earlier fields with the same key.
+func (e *Entry) flatFields() []Field {
+	return sortFields(e.appendFields(make([]Field, 0, 8)))
+}
 
-	callDepth := 1
-	// find the first package that isnt this one, excluding our tests
-	for pkgName == ownPkgName && !strings.HasSuffix(file, "_test.go") {
-		pc, file, line, _ = runtime.Caller(callDepth)
+// appendFields appends the fields of the entry's ancestors, oldest first, and
+// then its own.
+func (e *Entry) appendFields(fields []Field) []Field {
+	if e.parent != nil {
+		fields = e.parent.appendFields(fields)
+	}
+	for k, v := range e.fields {
+		fields = append(fields, Any(k, v))
+	}
+	return append(fields, e.typed...)
+}
 
-		files := strings.Split(file, "/")
-		file = files[len(files)-1]
+func (c *Config) prefix(level LogLevel) string {
+	if level == LogError {
+		return c.ErrorPrefix
+	} else if level == LogWarning {
+		return c.WarnPrefix
+	} else if level == LogInformational {
+		return c.InfoPrefix
+	}
+	return c.DebugPrefix
+}
 
-		pkgName = strings.Join(strings.Split(runtime.FuncForPC(pc).Name(), ".")[:2], ".")
+// withFixedCaller returns a new entry whose log points report the current
+// caller outside of this package as their caller. It is used for log points
+// written by goroutines started by this package, which have no other caller.
+func (e *Entry) withFixedCaller() *Entry {
+	caller := new(frame)
+	caller.file, caller.line, caller.function = getFunctionInfo(e.callerSkip)
+	child := e.derive()
+	child.caller = caller
+	return child
+}
 
-		name = runtime.FuncForPC(pc).Name()
-		fns := strings.Split(name, ".")
-		name = fns[len(fns)-1]
-		callDepth++
+// resolveCaller returns the caller of the log point, see getFunctionInfo.
+func (e *Entry) resolveCaller() (file string, line int, name string) {
+	if e.caller != nil {
+		return e.caller.file, e.caller.line, e.caller.function
 	}
+	return getFunctionInfo(e.callerSkip)
+}
 
+// getFunctionInfo returns the absolute file path, line and fully qualified
+// function name of the first caller outside of this package that isn't
+// marked as a helper, after skipping skip further frames.
+func getFunctionInfo(skip int) (file string, line int, name string) {
+	walkFrames(func(f frame) bool {
+		if isHelper(f.function) {
+			return true
+		}
+		if skip > 0 {
+			skip--
+			return true
+		}
+		file, line, name = f.file, f.line, f.function
+		return false
+	})
 	return
 }
@@ -0,0 +1,112 @@
+package log_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Strum355/log"
+)
+
+func Test_EntryImmutable(t *testing.T) {
+	defer b.Reset()
+	log.InitSimpleLogger(&log.Config{
+		Output: b,
+	})
+
+	base := log.WithFields(log.Fields{"base": 1})
+	first := base.WithFields(log.Fields{"first": 1}).With(log.Int("base", 2))
+	second := base.WithError(errors.New("second")).WithCallerSkip(0)
+	ctx := base.WithContext(context.WithValue(context.Background(), log.Key, log.Fields{"ctx": 1}))
+
+	tests := []struct {
+		name     string
+		entry    *log.Entry
+		expected string
+	}{
+		{
+			name:     "Base",
+			entry:    base,
+			expected: "\tbase='1'",
+		},
+		{
+			name:     "First",
+			entry:    first,
+			expected: "\tbase='2' first='1'",
+		},
+		{
+			name:     "Second",
+			entry:    second,
+			expected: "\tbase='1' error='second'",
+		},
+		{
+			name:     "Context",
+			entry:    ctx,
+			expected: "\tbase='1' ctx='1'",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer b.Reset()
+			test.entry.Info("immutable")
+
+			if lines := strings.Split(b.String(), "\n"); lines[1] != test.expected {
+				t.Errorf("expected fields: '%s'. actual fields: '%s'", test.expected, lines[1])
+			}
+		})
+	}
+}
+
+func Test_EntryConcurrentDerive(t *testing.T) {
+	log.InitSimpleLogger(&log.Config{
+		Output: ioutil.Discard,
+	})
+
+	base := log.WithFields(log.Fields{"base": true})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				e := base.WithFields(log.Fields{"goroutine": i}).With(log.Int("iteration", j))
+				e.WithError(errors.New(strconv.Itoa(j))).Info("derived")
+				expected := "derived base='true' goroutine='" + strconv.Itoa(i) + "' iteration='" + strconv.Itoa(j) + "'"
+				if err := e.Err("derived"); err.Error() != expected {
+					t.Errorf("expected error: '%s'. actual error: '%s'", expected, err.Error())
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func Test_WithFieldsCopies(t *testing.T) {
+	defer b.Reset()
+	log.InitSimpleLogger(&log.Config{
+		Output: b,
+	})
+
+	fields := log.Fields{"a": 1}
+	entry := log.WithFields(fields)
+	ctx := context.WithValue(context.Background(), log.Key, &fields)
+	ctxEntry := log.WithContext(ctx)
+
+	fields["a"] = 2
+	fields["b"] = 3
+
+	entry.Info("first")
+	ctxEntry.Info("second")
+
+	for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		if strings.HasPrefix(line, "\t") && line != "\ta='1'" {
+			t.Errorf("expected fields from before the map was modified: '%s'", line)
+		}
+	}
+}
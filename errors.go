@@ -0,0 +1,199 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// fieldsError is an error that carries Fields to be added to the entry it is
+// logged with.
+type fieldsError struct {
+	err    error
+	fields Fields
+}
+
+// WrapError wraps err with fields, which are added to the entry when the
+// returned error, or any error wrapping it, is passed to WithError. This
+// allows context known deep in the call stack to be logged where the error is
+// eventually handled. WrapError returns nil if err is nil.
+func WrapError(err error, fields Fields) error {
+	if err == nil {
+		return nil
+	}
+	return &fieldsError{err, fields}
+}
+
+func (e *fieldsError) Error() string {
+	return e.err.Error()
+}
+
+func (e *fieldsError) Unwrap() error {
+	return e.err
+}
+
+func (e *fieldsError) logFields() Fields {
+	return e.fields
+}
+
+// entryError is an error created from an Entry.
+type entryError struct {
+	msg    string
+	fields Fields
+}
+
+// Err returns an error with the message msg that carries the fields of the
+// entry. Its Error method renders the message and fields like the simple
+// logger does, and WithError restores the fields when it is logged. If the
+// entry has an error, see WithError, it is wrapped by the returned error.
+func (e *Entry) Err(msg string) error {
+	flat := e.flatFields()
+	fields := make(Fields, len(flat))
+	for _, f := range flat {
+		fields[f.Key] = f.value()
+	}
+	return &entryError{msg, fields}
+}
+
+func (e *entryError) Error() string {
+	if len(e.fields) == 0 {
+		return e.msg
+	}
+	return e.msg + " " + strings.TrimSpace(e.fields.format(false))
+}
+
+// redactedMessage returns the message of e with the values of fields whose
+// keys match the configured key patterns of c masked, if there are any.
+func (e *entryError) redactedMessage(c *Config) (string, bool) {
+	var fields Fields
+	for k := range e.fields {
+		if c.isRedactedKey(k) {
+			if fields == nil {
+				fields = make(Fields, len(e.fields))
+				for k, v := range e.fields {
+					fields[k] = v
+				}
+			}
+			fields[k] = redacted
+		}
+	}
+	if fields == nil {
+		return "", false
+	}
+	return (&entryError{e.msg, fields}).Error(), true
+}
+
+func (e *entryError) Unwrap() error {
+	err, _ := e.fields["error"].(error)
+	return err
+}
+
+func (e *entryError) logFields() Fields {
+	return e.fields
+}
+
+// errorFields returns the merged Fields carried by all errors in the chain of
+// err. By default fields of inner errors take precedence over those of outer
+// errors, unless Config.OuterErrorFieldsWin is set in the configuration the
+// entry logs with.
+func (e *Entry) errorFields(err error) Fields {
+	c := e.config()
+	return errorFields(err, c != nil && c.OuterErrorFieldsWin)
+}
+
+// errorFields returns the merged Fields carried by all errors in the chain of
+// err, giving fields of outer errors precedence if outerWins is set.
+func errorFields(err error, outerWins bool) Fields {
+	var carried []Fields
+	walkErrors(err, func(err error) {
+		if e, ok := err.(interface{ logFields() Fields }); ok {
+			carried = append(carried, e.logFields())
+		}
+	})
+
+	if len(carried) == 0 {
+		return nil
+	}
+
+	fields := make(Fields)
+	for i := range carried {
+		f := carried[i]
+		if outerWins {
+			f = carried[len(carried)-1-i]
+		}
+		for k, v := range f {
+			fields[k] = v
+		}
+	}
+	return fields
+}
+
+// walkErrors calls f for every error in the tree of err, outermost first,
+// including the errors wrapped by multi-errors.
+func walkErrors(err error, f func(error)) {
+	for err != nil {
+		f(err)
+
+		if multi, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, err := range multi.Unwrap() {
+				walkErrors(err, f)
+			}
+			return
+		}
+		err = errors.Unwrap(err)
+	}
+}
+
+// errorLayer is a single error in the chain of a logged error.
+type errorLayer struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	// Errors holds the chains of the errors wrapped by a multi-error such as
+	// one returned by errors.Join.
+	Errors [][]errorLayer `json:"errors,omitempty"`
+}
+
+// errorChain returns the layers of the unwrap chain of err, outermost first.
+func errorChain(err error) []errorLayer {
+	if redacted, ok := err.(*redactedError); ok {
+		return redacted.chain
+	}
+
+	var chain []errorLayer
+	for err != nil {
+		layer := errorLayer{
+			Message: err.Error(),
+			Type:    fmt.Sprintf("%T", err),
+		}
+
+		if multi, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, err := range multi.Unwrap() {
+				if err != nil {
+					layer.Errors = append(layer.Errors, errorChain(err))
+				}
+			}
+			return append(chain, layer)
+		}
+
+		chain = append(chain, layer)
+		err = errors.Unwrap(err)
+	}
+	return chain
+}
+
+// hasErrorChain reports whether err wraps any other errors.
+func hasErrorChain(err error) bool {
+	if redacted, ok := err.(*redactedError); ok {
+		return redacted.chain != nil
+	}
+	if _, ok := err.(interface{ Unwrap() []error }); ok {
+		return true
+	}
+	return errors.Unwrap(err) != nil
+}
+
+// errorSummary returns the message of err on a single line, as the messages
+// of multi-errors such as those returned by errors.Join span multiple lines.
+func errorSummary(err error) string {
+	return strings.Replace(err.Error(), "\n", "; ", -1)
+}
@@ -0,0 +1,236 @@
+package log_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Strum355/log"
+)
+
+type customError struct{}
+
+func (customError) Error() string { return "custom" }
+
+func Test_ErrorChain(t *testing.T) {
+	defer b.Reset()
+
+	err := fmt.Errorf("outer: %w", errors.Join(
+		customError{},
+		fmt.Errorf("inner: %w", errors.New("root")),
+	))
+
+	t.Run("JSON", func(t *testing.T) {
+		defer b.Reset()
+		log.InitJSONLogger(&log.Config{
+			Output: b,
+		})
+
+		log.WithError(err).Error("failed")
+
+		var data map[string]interface{}
+		if err := json.Unmarshal([]byte(b.String()), &data); err != nil {
+			t.Fatalf("error unmarshalling buffer: %v", err)
+		}
+
+		if data["error"] != err.Error() {
+			t.Errorf("expected error summary: '%v'", data["error"])
+		}
+
+		expected := []interface{}{
+			map[string]interface{}{
+				"message": "outer: custom\ninner: root",
+				"type":    "*fmt.wrapError",
+			},
+			map[string]interface{}{
+				"message": "custom\ninner: root",
+				"type":    "*errors.joinError",
+				"errors": []interface{}{
+					[]interface{}{
+						map[string]interface{}{
+							"message": "custom",
+							"type":    "log_test.customError",
+						},
+					},
+					[]interface{}{
+						map[string]interface{}{
+							"message": "inner: root",
+							"type":    "*fmt.wrapError",
+						},
+						map[string]interface{}{
+							"message": "root",
+							"type":    "*errors.errorString",
+						},
+					},
+				},
+			},
+		}
+
+		if !reflect.DeepEqual(data["error_chain"], expected) {
+			t.Errorf("unexpected error chain: %#v", data["error_chain"])
+		}
+	})
+
+	t.Run("JSONUnwrapped", func(t *testing.T) {
+		defer b.Reset()
+		log.InitJSONLogger(&log.Config{
+			Output: b,
+		})
+
+		log.WithError(errors.New("plain")).Error("failed")
+
+		if strings.Contains(b.String(), "error_chain") {
+			t.Errorf("expected no chain for unwrapped error: '%s'", b.String())
+		}
+	})
+
+	t.Run("Simple", func(t *testing.T) {
+		defer b.Reset()
+		log.InitSimpleLogger(&log.Config{
+			Output: b,
+		})
+
+		log.WithError(err).Error("failed")
+
+		if ok, fields := hasField("error", "outer: custom; inner: root", b.String(), t); !ok {
+			t.Errorf("expected single line error summary. actual fields total: %s", fields)
+		}
+
+		if strings.Count(b.String(), "\n") != 2 {
+			t.Errorf("expected two lines: '%s'", b.String())
+		}
+	})
+}
+
+func Test_WrapError(t *testing.T) {
+	defer b.Reset()
+
+	inner := log.WrapError(errors.New("not found"), log.Fields{
+		"user_id": 42,
+		"shard":   "inner",
+	})
+	outer := log.WrapError(fmt.Errorf("loading profile: %w", inner), log.Fields{
+		"request_id": "abc",
+		"shard":      "outer",
+	})
+
+	tests := []struct {
+		name      string
+		outerWins bool
+		shard     string
+	}{
+		{
+			name:  "InnerWins",
+			shard: "inner",
+		},
+		{
+			name:      "OuterWins",
+			outerWins: true,
+			shard:     "outer",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer b.Reset()
+			log.InitSimpleLogger(&log.Config{
+				Output:              b,
+				OuterErrorFieldsWin: test.outerWins,
+			})
+
+			log.WithFields(log.Fields{"shard": "entry"}).WithError(outer).Error("failed")
+
+			expected := log.Fields{
+				"user_id":    42,
+				"request_id": "abc",
+				"shard":      test.shard,
+				"error":      "loading profile: not found",
+			}
+			for k, v := range expected {
+				if ok, fields := hasField(k, v, b.String(), t); !ok {
+					t.Errorf("expected fields to contain: '%s=%v'. actual fields total: %s", k, v, fields)
+				}
+			}
+		})
+	}
+
+	if log.WrapError(nil, log.Fields{"a": 1}) != nil {
+		t.Error("expected wrapping nil error to return nil")
+	}
+
+	if !errors.Is(outer, errors.Unwrap(inner)) {
+		t.Error("expected wrapped error to be unwrappable")
+	}
+}
+
+func Test_WrapErrorLoggerConfig(t *testing.T) {
+	log.InitSimpleLogger(&log.Config{
+		Output: ioutil.Discard,
+	})
+
+	inner := log.WrapError(errors.New("not found"), log.Fields{"shard": "inner"})
+	outer := log.WrapError(fmt.Errorf("loading profile: %w", inner), log.Fields{"shard": "outer"})
+
+	var out bytes.Buffer
+	logger, err := log.New(log.WithConfig(&log.Config{
+		Output:              &out,
+		OuterErrorFieldsWin: true,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger.WithError(outer).Error("failed")
+	logger.With(log.Err(outer)).Error("failed")
+
+	if ok, fields := hasField("shard", "outer", out.String(), t); !ok {
+		t.Errorf("expected the logger's configuration to give the outer error precedence: %s", fields)
+	}
+	if strings.Contains(out.String(), "inner") {
+		t.Errorf("expected no fields of the inner error: '%s'", out.String())
+	}
+}
+
+func Test_EntryErr(t *testing.T) {
+	defer b.Reset()
+	log.InitSimpleLogger(&log.Config{
+		Output: b,
+	})
+
+	cause := errors.New("connection refused")
+	err := log.WithFields(log.Fields{
+		"user_id": 42,
+		"shard":   "eu-1",
+	}).WithError(cause).Err("loading profile")
+
+	expected := "loading profile error='connection refused' shard='eu-1' user_id='42'"
+	if err.Error() != expected {
+		t.Errorf("expected error: '%s'. actual error: '%s'", expected, err.Error())
+	}
+
+	if !errors.Is(err, cause) {
+		t.Error("expected entry error to wrap the entry's error")
+	}
+
+	if log.WithFields(log.Fields{}).Err("bare").Error() != "bare" {
+		t.Error("expected error without fields to only contain the message")
+	}
+
+	log.WithError(fmt.Errorf("handling request: %w", err)).Error("request failed")
+
+	restored := log.Fields{
+		"user_id": 42,
+		"shard":   "eu-1",
+		"error":   "handling request: " + expected,
+	}
+	for k, v := range restored {
+		if ok, fields := hasField(k, v, b.String(), t); !ok {
+			t.Errorf("expected fields to contain: '%s=%v'. actual fields total: %s", k, v, fields)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package log
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+type fieldType uint8
+
+const (
+	anyType fieldType = iota
+	stringType
+	intType
+	uintType
+	floatType
+	boolType
+	durationType
+	timeType
+	errorType
+)
+
+// Field is a typed key-value pair that can be added to an entry with With.
+// Unlike Fields, typed fields don't need a map or to box their values, and
+// are written by the loggers without reflection.
+type Field struct {
+	Key     string
+	typ     fieldType
+	integer int64
+	str     string
+	iface   interface{}
+}
+
+// String returns a Field holding a string.
+func String(key, value string) Field {
+	return Field{Key: key, typ: stringType, str: value}
+}
+
+// Int returns a Field holding an int.
+func Int(key string, value int) Field {
+	return Field{Key: key, typ: intType, integer: int64(value)}
+}
+
+// Int64 returns a Field holding an int64.
+func Int64(key string, value int64) Field {
+	return Field{Key: key, typ: intType, integer: value}
+}
+
+// Uint64 returns a Field holding a uint64.
+func Uint64(key string, value uint64) Field {
+	return Field{Key: key, typ: uintType, integer: int64(value)}
+}
+
+// Float64 returns a Field holding a float64.
+func Float64(key string, value float64) Field {
+	return Field{Key: key, typ: floatType, integer: int64(math.Float64bits(value))}
+}
+
+// Bool returns a Field holding a bool.
+func Bool(key string, value bool) Field {
+	var integer int64
+	if value {
+		integer = 1
+	}
+	return Field{Key: key, typ: boolType, integer: integer}
+}
+
+// Duration returns a Field holding a time.Duration.
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, typ: durationType, integer: int64(value)}
+}
+
+// Time returns a Field holding a time.Time.
+func Time(key string, value time.Time) Field {
+	if sec := value.Unix(); sec <= minUnixNanoSec || sec >= maxUnixNanoSec {
+		// UnixNano is undefined outside of years 1678 to 2262, so the time
+		// is boxed instead, without its monotonic clock reading
+		return Field{Key: key, typ: timeType, iface: value.Round(0)}
+	}
+	return Field{Key: key, typ: timeType, integer: value.UnixNano(), iface: value.Location()}
+}
+
+// The range of Unix times in seconds, exclusive, that can be represented in
+// nanoseconds by an int64.
+const (
+	minUnixNanoSec = math.MinInt64 / int64(time.Second)
+	maxUnixNanoSec = math.MaxInt64 / int64(time.Second)
+)
+
+// Err returns a Field holding err with the key "error", the same key used by
+// WithError.
+func Err(err error) Field {
+	return NamedErr("error", err)
+}
+
+// NamedErr returns a Field holding err with the given key.
+func NamedErr(key string, err error) Field {
+	if err == nil {
+		return Field{Key: key, typ: anyType}
+	}
+	return Field{Key: key, typ: errorType, iface: err}
+}
+
+// Any returns a Field holding an arbitrary value, which is written the same
+// way as values in Fields.
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, typ: anyType, iface: value}
+}
+
+// With adds typed fields to a new entry.
+func With(fields ...Field) *Entry {
+	return emptyEntry.With(fields...)
+}
+
+// With returns a new entry with typed fields added to the fields of e. Fields
+// carried by errors added with Err are added to the entry too, see WithError.
+// fields must not be modified afterwards.
+func (e *Entry) With(fields ...Field) *Entry {
+	for _, f := range fields {
+		if f.typ != errorType {
+			continue
+		}
+		if carried := e.errorFields(f.iface.(error)); carried != nil {
+			e = e.withFields(carried)
+		}
+	}
+	child := e.derive()
+	child.typed = fields
+	return child
+}
+
+// value returns the value of the field as it would be stored in Fields.
+func (f Field) value() interface{} {
+	switch f.typ {
+	case stringType:
+		return f.str
+	case intType:
+		return f.integer
+	case uintType:
+		return uint64(f.integer)
+	case floatType:
+		return math.Float64frombits(uint64(f.integer))
+	case boolType:
+		return f.integer == 1
+	case durationType:
+		return time.Duration(f.integer)
+	case timeType:
+		return f.time()
+	}
+	return f.iface
+}
+
+func (f Field) time() time.Time {
+	if t, ok := f.iface.(time.Time); ok {
+		return t
+	}
+	t := time.Unix(0, f.integer)
+	if loc, ok := f.iface.(*time.Location); ok {
+		t = t.In(loc)
+	}
+	return t
+}
+
+// error returns the error held by the field, if any.
+func (f Field) error() (error, bool) {
+	err, ok := f.iface.(error)
+	return err, ok && (f.typ == errorType || f.typ == anyType)
+}
+
+// stringValue returns the string held by the field, if any.
+func (f Field) stringValue() (string, bool) {
+	switch f.typ {
+	case stringType:
+		return f.str, true
+	case anyType:
+		s, ok := f.iface.(string)
+		return s, ok
+	}
+	return "", false
+}
+
+// appendText appends the value of the field as formatted by the %v verb,
+// without boxing it if it is typed.
+func (f Field) appendText(b []byte) []byte {
+	switch f.typ {
+	case stringType:
+		return append(b, f.str...)
+	case intType:
+		return strconv.AppendInt(b, f.integer, 10)
+	case uintType:
+		return strconv.AppendUint(b, uint64(f.integer), 10)
+	case floatType:
+		return strconv.AppendFloat(b, math.Float64frombits(uint64(f.integer)), 'g', -1, 64)
+	case boolType:
+		return strconv.AppendBool(b, f.integer == 1)
+	case durationType:
+		return append(b, time.Duration(f.integer).String()...)
+	case timeType:
+		return appendTimeText(b, f.time())
+	case errorType:
+		return append(b, errorSummary(f.iface.(error))...)
+	}
+
+	switch v := f.iface.(type) {
+	case string:
+		return append(b, v...)
+	case error:
+		return append(b, errorSummary(v)...)
+	case time.Time:
+		return appendTimeText(b, v)
+	}
+	return fmt.Append(b, f.iface)
+}
+
+// appendTimeText appends t as formatted by time.Time.String.
+func appendTimeText(b []byte, t time.Time) []byte {
+	if t != t.Round(0) {
+		// only String includes the monotonic clock reading
+		return append(b, t.String()...)
+	}
+	return t.AppendFormat(b, "2006-01-02 15:04:05.999999999 -0700 MST")
+}
+
+// fieldSlice returns the fields as a slice sorted by key.
+func (f Fields) fieldSlice() []Field {
+	fields := make([]Field, 0, len(f))
+	for k, v := range f {
+		fields = append(fields, Any(k, v))
+	}
+	return sortFields(fields)
+}
+
+// sortFields sorts fields by key, removing all but the last of any fields
+// with the same key. Fields are usually few, so an allocation free insertion
+// sort is used.
+func sortFields(fields []Field) []Field {
+	for i := 1; i < len(fields); i++ {
+		for j := i; j > 0 && fields[j].Key < fields[j-1].Key; j-- {
+			fields[j], fields[j-1] = fields[j-1], fields[j]
+		}
+	}
+
+	// the sort is stable, so the last of any duplicates was added last
+	out := fields[:0]
+	for i, f := range fields {
+		if i+1 < len(fields) && fields[i+1].Key == f.Key {
+			continue
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+// appendSimpleFields appends fields the way the simple logger formats them,
+// as space-separated key='value' pairs.
+func appendSimpleFields(b []byte, fields []Field, color bool) []byte {
+	for i, f := range fields {
+		if i > 0 {
+			b = append(b, ' ')
+		}
+		if color {
+			b = append(b, colorCyan...)
+			b = append(b, f.Key...)
+			b = append(b, colorReset...)
+		} else {
+			b = append(b, f.Key...)
+		}
+		b = append(b, "='"...)
+		b = f.appendText(b)
+		b = append(b, '\'')
+	}
+	return b
+}
@@ -0,0 +1,50 @@
+package log
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func Benchmark_Fields(b *testing.B) {
+	InitSimpleLogger(&Config{
+		Output: ioutil.Discard,
+	})
+
+	b.Run("Map", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			WithFields(Fields{
+				"user_id":  42,
+				"region":   "eu",
+				"duration": time.Second,
+			}).Info("benchmark")
+		}
+	})
+
+	b.Run("Typed", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			With(
+				Int("user_id", 42),
+				String("region", "eu"),
+				Duration("duration", time.Second),
+			).Info("benchmark")
+		}
+	})
+}
+
+func Benchmark_JSONLogger(b *testing.B) {
+	InitJSONLogger(&Config{
+		Output: ioutil.Discard,
+	})
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		With(
+			Int("user_id", 42),
+			String("region", "eu"),
+			Duration("duration", time.Second),
+		).Info("benchmark")
+	}
+}
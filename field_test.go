@@ -0,0 +1,145 @@
+package log_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Strum355/log"
+)
+
+func Test_TypedFields(t *testing.T) {
+	defer b.Reset()
+
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	entry := func() *log.Entry {
+		return log.With(
+			log.String("string", "text"),
+			log.Int("int", -1),
+			log.Uint64("uint", 1<<63),
+			log.Float64("float", 1.5),
+			log.Bool("bool", true),
+			log.Duration("duration", 1500*time.Millisecond),
+			log.Time("time_field", ts),
+			log.Err(errors.New("failed")),
+			log.Any("any", []int{1, 2}),
+		)
+	}
+
+	t.Run("Simple", func(t *testing.T) {
+		defer b.Reset()
+		log.InitSimpleLogger(&log.Config{
+			Output: b,
+		})
+
+		entry().Info("typed")
+
+		expected := "\tany='[1 2]' bool='true' duration='1.5s' error='failed' float='1.5' int='-1' string='text' time_field='2020-01-02 03:04:05.000000006 +0000 UTC' uint='9223372036854775808'\n"
+		if lines := strings.SplitAfter(b.String(), "\n"); lines[1] != expected {
+			t.Errorf("expected fields: '%s'. actual fields: '%s'", expected, lines[1])
+		}
+	})
+
+	t.Run("Logfmt", func(t *testing.T) {
+		defer b.Reset()
+		log.InitLogfmtLogger(&log.Config{
+			Output: b,
+		})
+
+		entry().Info("typed")
+
+		expected := ` msg=typed any="[1 2]" bool=true duration=1.5s error=failed float=1.5 int=-1 string=text time_field="2020-01-02 03:04:05.000000006 +0000 UTC" uint=9223372036854775808` + "\n"
+		if !strings.HasSuffix(b.String(), expected) {
+			t.Errorf("expected suffix: '%s'. actual: '%s'", expected, b.String())
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		defer b.Reset()
+		log.InitJSONLogger(&log.Config{
+			Output: b,
+		})
+
+		entry().Info("typed")
+
+		var data map[string]interface{}
+		if err := json.Unmarshal([]byte(b.String()), &data); err != nil {
+			t.Fatalf("error unmarshalling buffer: %v", err)
+		}
+
+		expected := map[string]interface{}{
+			"string":     "text",
+			"int":        float64(-1),
+			"uint":       float64(1 << 63),
+			"float":      1.5,
+			"bool":       true,
+			"duration":   float64(1500 * time.Millisecond),
+			"time_field": "2020-01-02T03:04:05.000000006Z",
+			"error":      "failed",
+		}
+		for k, v := range expected {
+			if data[k] != v {
+				t.Errorf("expected value for '%s': %T '%v'. actual value %T '%v'", k, v, v, data[k], data[k])
+			}
+		}
+	})
+
+	t.Run("Precedence", func(t *testing.T) {
+		defer b.Reset()
+		log.InitSimpleLogger(&log.Config{
+			Output: b,
+		})
+
+		log.WithFields(log.Fields{
+			"key":   "map",
+			"other": "map",
+		}).With(log.String("key", "typed"), log.Int("key", 2)).Info("precedence")
+
+		if lines := strings.Split(b.String(), "\n"); lines[1] != "\tkey='2' other='map'" {
+			t.Errorf("expected last typed field to win: '%s'", lines[1])
+		}
+	})
+
+	t.Run("CarriedErrorFields", func(t *testing.T) {
+		defer b.Reset()
+		log.InitSimpleLogger(&log.Config{
+			Output: b,
+		})
+
+		log.With(log.Err(log.WrapError(errors.New("failed"), log.Fields{"user_id": 42}))).Error("carried")
+
+		if ok, fields := hasField("user_id", 42, b.String(), t); !ok {
+			t.Errorf("expected carried fields to be added: '%s'", fields)
+		}
+	})
+}
+
+func Test_TimeFieldRange(t *testing.T) {
+	defer b.Reset()
+	log.InitJSONLogger(&log.Config{
+		Output: b,
+	})
+
+	tests := []time.Time{
+		{},
+		time.Date(1600, 1, 2, 3, 4, 5, 6, time.UTC),
+		time.Date(3000, 1, 2, 3, 4, 5, 6, time.FixedZone("X", 3600)),
+		time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC),
+	}
+
+	for _, ts := range tests {
+		b.Reset()
+		log.With(log.Time("t", ts)).Info("time")
+
+		var data map[string]interface{}
+		if err := json.Unmarshal([]byte(b.String()), &data); err != nil {
+			t.Fatalf("error unmarshalling buffer: %v", err)
+		}
+
+		if expected := ts.Format(time.RFC3339Nano); data["t"] != expected {
+			t.Errorf("expected time: '%s'. actual time: '%v'", expected, data["t"])
+		}
+	}
+}
@@ -1,52 +1,44 @@
 package log
 
-import (
-	"fmt"
-	"sort"
-	"strings"
-)
-
 type Fields map[string]interface{}
 
+// WithFields adds f to a new entry.
 func WithFields(f Fields) *Entry {
-	return &Entry{fields: f}
+	return emptyEntry.WithFields(f)
 }
 
+// WithFields returns a new entry with f added to the fields of e. f is
+// copied, so it can be modified afterwards without affecting the entry.
 func (e *Entry) WithFields(f Fields) *Entry {
-	if e.fields == nil {
-		e.fields = make(Fields)
-	}
+	fields := make(Fields, len(f))
 	for k, v := range f {
-		e.fields[k] = v
+		fields[k] = v
 	}
-	return e
+	return e.withFields(fields)
+}
+
+// withFields returns a new entry with f added to the fields of e, without
+// copying f.
+func (e *Entry) withFields(f Fields) *Entry {
+	child := e.derive()
+	child.fields = f
+	return child
+}
+
+// processFields applies the configured field processors to fields before they
+// are passed to the logger. Pseudonymization is applied before redaction, so
+// redaction takes precedence for keys configured for both.
+func (c *Config) processFields(fields []Field) {
+	c.Pseudonymizer.pseudonymizeFields(fields)
+	c.redactFields(fields)
 }
 
-func (f Fields) format() string {
+func (f Fields) format(color bool) string {
 	if f == nil || len(f) == 0 {
 		return ""
 	}
 
-	keys := make([]string, 0, len(f))
-	for k := range f {
-		keys = append(keys, k)
-	}
-
-	sort.Strings(keys)
-
-	var builder strings.Builder
-	builder.WriteRune('\t')
-
-	var iterCount int
-	for _, k := range keys {
-		v := f[k]
-		s := strings.TrimSpace(fmt.Sprintf("%s='%v'", k, v))
-		if iterCount < len(f)-1 {
-			s += " "
-		}
-		builder.WriteString(s)
-		iterCount++
-	}
-	builder.WriteRune('\n')
-	return builder.String()
+	b := []byte{'\t'}
+	b = appendSimpleFields(b, f.fieldSlice(), color)
+	return string(append(b, '\n'))
 }
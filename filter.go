@@ -0,0 +1,429 @@
+package log
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// filter is a parsed filter expression, see Config.Filter. Log points
+// matching it are written regardless of their level.
+//
+// The grammar of filter expressions is
+//
+//	expr       = and { "||" and }
+//	and        = unary { "&&" unary }
+//	unary      = "!" unary | "(" expr ")" | comparison
+//	comparison = operand [ ( "==" | "!=" ) operand ]
+//	operand    = string | number | "true" | "false" | identifier
+//
+// where strings are double quoted with Go escapes and identifiers are the
+// keys of fields, or one of level, msg, caller (e.g. main.go:42) and func
+// (the fully qualified function name) which take precedence over fields with
+// the same key. An operand on its own is true if it is present and isn't
+// false, zero or empty.
+type filter struct {
+	expr string
+	root filterNode
+}
+
+// filterNode is a node of a parsed filter expression.
+type filterNode interface {
+	eval(log *logPoint) bool
+}
+
+type (
+	andNode struct{ left, right filterNode }
+	orNode  struct{ left, right filterNode }
+	notNode struct{ node filterNode }
+	// compareNode compares its operands for equality, or inequality if
+	// negate is set
+	compareNode struct {
+		left, right operand
+		negate      bool
+	}
+	// truthNode is an operand on its own
+	truthNode struct{ operand operand }
+)
+
+func (n andNode) eval(log *logPoint) bool { return n.left.eval(log) && n.right.eval(log) }
+func (n orNode) eval(log *logPoint) bool  { return n.left.eval(log) || n.right.eval(log) }
+func (n notNode) eval(log *logPoint) bool { return !n.node.eval(log) }
+
+func (n compareNode) eval(log *logPoint) bool {
+	return n.left.value(log).equal(n.right.value(log)) != n.negate
+}
+
+func (n truthNode) eval(log *logPoint) bool {
+	v := n.operand.value(log)
+	switch v.kind {
+	case boolValue:
+		return v.b
+	case numberValue:
+		return v.num != 0
+	case stringValue:
+		return v.str != ""
+	}
+	return false
+}
+
+type operandKind uint8
+
+const (
+	literalOperand operandKind = iota
+	fieldOperand
+	levelOperand
+	msgOperand
+	callerOperand
+	funcOperand
+)
+
+type operand struct {
+	kind    operandKind
+	key     string
+	literal filterValue
+}
+
+// value returns the value of the operand for log.
+func (o operand) value(log *logPoint) filterValue {
+	switch o.kind {
+	case literalOperand:
+		return o.literal
+	case levelOperand:
+		return filterValue{kind: stringValue, str: log.level.String()}
+	case msgOperand:
+		return filterValue{kind: stringValue, str: log.msg}
+	case callerOperand:
+		return filterValue{kind: stringValue, str: path.Base(log.file) + ":" + strconv.Itoa(log.fileLine)}
+	case funcOperand:
+		return filterValue{kind: stringValue, str: log.funcName}
+	}
+
+	// the fields are sorted by key
+	i := sort.Search(len(log.fields), func(i int) bool { return log.fields[i].Key >= o.key })
+	if i == len(log.fields) || log.fields[i].Key != o.key {
+		return filterValue{}
+	}
+	return fieldFilterValue(log.fields[i])
+}
+
+type valueKind uint8
+
+const (
+	missingValue valueKind = iota
+	stringValue
+	numberValue
+	boolValue
+)
+
+type filterValue struct {
+	kind valueKind
+	str  string
+	num  float64
+	b    bool
+}
+
+// equal reports whether v and w are equal. Values of different kinds are
+// compared as text, so that e.g. the string "42" equals the number 42.
+// Missing values aren't equal to anything.
+func (v filterValue) equal(w filterValue) bool {
+	if v.kind == missingValue || w.kind == missingValue {
+		return false
+	}
+	if v.kind == w.kind {
+		return v.str == w.str && v.num == w.num && v.b == w.b
+	}
+	return v.text() == w.text()
+}
+
+func (v filterValue) text() string {
+	switch v.kind {
+	case numberValue:
+		return strconv.FormatFloat(v.num, 'f', -1, 64)
+	case boolValue:
+		return strconv.FormatBool(v.b)
+	}
+	return v.str
+}
+
+func fieldFilterValue(f Field) filterValue {
+	switch f.typ {
+	case stringType:
+		return filterValue{kind: stringValue, str: f.str}
+	case intType:
+		return filterValue{kind: numberValue, num: float64(f.integer)}
+	case uintType:
+		return filterValue{kind: numberValue, num: float64(uint64(f.integer))}
+	case floatType:
+		return filterValue{kind: numberValue, num: math.Float64frombits(uint64(f.integer))}
+	case boolType:
+		return filterValue{kind: boolValue, b: f.integer == 1}
+	case durationType:
+		return filterValue{kind: stringValue, str: time.Duration(f.integer).String()}
+	}
+
+	switch v := f.iface.(type) {
+	case nil:
+		return filterValue{}
+	case bool:
+		return filterValue{kind: boolValue, b: v}
+	case int:
+		return filterValue{kind: numberValue, num: float64(v)}
+	case int64:
+		return filterValue{kind: numberValue, num: float64(v)}
+	case int32:
+		return filterValue{kind: numberValue, num: float64(v)}
+	case uint:
+		return filterValue{kind: numberValue, num: float64(v)}
+	case uint64:
+		return filterValue{kind: numberValue, num: float64(v)}
+	case uint32:
+		return filterValue{kind: numberValue, num: float64(v)}
+	case float64:
+		return filterValue{kind: numberValue, num: v}
+	case float32:
+		return filterValue{kind: numberValue, num: float64(v)}
+	}
+	return filterValue{kind: stringValue, str: string(f.appendText(nil))}
+}
+
+// match reports whether log matches the filter. log is passed by value, so
+// that it only escapes to the heap when there is a filter.
+func (f *filter) match(log logPoint) bool {
+	return f.root.eval(&log)
+}
+
+// parseFilter parses a filter expression.
+func parseFilter(expr string) (*filter, error) {
+	tokens, err := lexFilter(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %q: %w", expr, err)
+	}
+
+	p := &filterParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err == nil && p.peek().kind != endToken {
+		err = p.unexpected()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %q: %w", expr, err)
+	}
+	return &filter{expr: expr, root: root}, nil
+}
+
+type tokenKind uint8
+
+const (
+	endToken tokenKind = iota
+	andToken
+	orToken
+	notToken
+	equalToken
+	notEqualToken
+	openToken
+	closeToken
+	stringToken
+	numberToken
+	identToken
+)
+
+type filterToken struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// lexFilter splits a filter expression into tokens, ending with an
+// endToken.
+func lexFilter(expr string) ([]filterToken, error) {
+	var tokens []filterToken
+	for i := 0; i < len(expr); {
+		r, size := utf8.DecodeRuneInString(expr[i:])
+		start := i
+
+		var kind tokenKind
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+			continue
+		case r == '(':
+			kind, i = openToken, i+1
+		case r == ')':
+			kind, i = closeToken, i+1
+		case hasPrefixAt(expr, i, "&&"):
+			kind, i = andToken, i+2
+		case hasPrefixAt(expr, i, "||"):
+			kind, i = orToken, i+2
+		case hasPrefixAt(expr, i, "=="):
+			kind, i = equalToken, i+2
+		case hasPrefixAt(expr, i, "!="):
+			kind, i = notEqualToken, i+2
+		case r == '!':
+			kind, i = notToken, i+1
+		case r == '"':
+			end := i + 1
+			for end < len(expr) && expr[end] != '"' {
+				if expr[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(expr) {
+				return nil, fmt.Errorf("unterminated string at offset %d", start)
+			}
+			kind, i = stringToken, end+1
+		case r == '-' || r == '.' || (r >= '0' && r <= '9'):
+			i++
+			for i < len(expr) && isNumberByte(expr[i]) {
+				i++
+			}
+			kind = numberToken
+		case r == '_' || unicode.IsLetter(r):
+			for i < len(expr) {
+				r, size := utf8.DecodeRuneInString(expr[i:])
+				if r != '_' && r != '.' && r != '-' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				i += size
+			}
+			kind = identToken
+		default:
+			return nil, fmt.Errorf("unexpected %q at offset %d", r, start)
+		}
+		tokens = append(tokens, filterToken{kind: kind, text: expr[start:i], pos: start})
+	}
+	return append(tokens, filterToken{kind: endToken, pos: len(expr)}), nil
+}
+
+func hasPrefixAt(s string, i int, prefix string) bool {
+	return len(s)-i >= len(prefix) && s[i:i+len(prefix)] == prefix
+}
+
+func isNumberByte(c byte) bool {
+	return c >= '0' && c <= '9' || c == '.' || c == 'e' || c == 'E' || c == '+' || c == '-'
+}
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() filterToken {
+	t := p.tokens[p.pos]
+	if t.kind != endToken {
+		p.pos++
+	}
+	return t
+}
+
+func (p *filterParser) unexpected() error {
+	t := p.peek()
+	if t.kind == endToken {
+		return fmt.Errorf("unexpected end of expression")
+	}
+	return fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+}
+
+func (p *filterParser) parseOr() (filterNode, error) {
+	left, err := p.parseAnd()
+	for err == nil && p.peek().kind == orToken {
+		p.next()
+		var right filterNode
+		if right, err = p.parseAnd(); err == nil {
+			left = orNode{left, right}
+		}
+	}
+	return left, err
+}
+
+func (p *filterParser) parseAnd() (filterNode, error) {
+	left, err := p.parseUnary()
+	for err == nil && p.peek().kind == andToken {
+		p.next()
+		var right filterNode
+		if right, err = p.parseUnary(); err == nil {
+			left = andNode{left, right}
+		}
+	}
+	return left, err
+}
+
+func (p *filterParser) parseUnary() (filterNode, error) {
+	switch p.peek().kind {
+	case notToken:
+		p.next()
+		node, err := p.parseUnary()
+		return notNode{node}, err
+	case openToken:
+		p.next()
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != closeToken {
+			return nil, p.unexpected()
+		}
+		p.next()
+		return node, nil
+	}
+
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	if kind := p.peek().kind; kind == equalToken || kind == notEqualToken {
+		p.next()
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return compareNode{left: left, right: right, negate: kind == notEqualToken}, nil
+	}
+	return truthNode{left}, nil
+}
+
+func (p *filterParser) parseOperand() (operand, error) {
+	t := p.peek()
+	switch t.kind {
+	case stringToken:
+		s, err := strconv.Unquote(t.text)
+		if err != nil {
+			return operand{}, fmt.Errorf("invalid string %s at offset %d", t.text, t.pos)
+		}
+		p.next()
+		return operand{literal: filterValue{kind: stringValue, str: s}}, nil
+	case numberToken:
+		n, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return operand{}, fmt.Errorf("invalid number %q at offset %d", t.text, t.pos)
+		}
+		p.next()
+		return operand{literal: filterValue{kind: numberValue, num: n}}, nil
+	case identToken:
+		p.next()
+		switch t.text {
+		case "true", "false":
+			return operand{literal: filterValue{kind: boolValue, b: t.text == "true"}}, nil
+		case "level":
+			return operand{kind: levelOperand}, nil
+		case "msg":
+			return operand{kind: msgOperand}, nil
+		case "caller":
+			return operand{kind: callerOperand}, nil
+		case "func":
+			return operand{kind: funcOperand}, nil
+		}
+		return operand{kind: fieldOperand, key: t.text}, nil
+	}
+	return operand{}, p.unexpected()
+}
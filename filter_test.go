@@ -0,0 +1,112 @@
+package log_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Strum355/log"
+)
+
+func Test_Filter(t *testing.T) {
+	tests := []struct {
+		filter   string
+		fields   []log.Field
+		expected bool
+	}{
+		{`user_id == "42" && region == "eu"`, []log.Field{log.String("user_id", "42"), log.String("region", "eu")}, true},
+		{`user_id == "42" && region == "eu"`, []log.Field{log.String("user_id", "42"), log.String("region", "us")}, false},
+		{`user_id == "42"`, []log.Field{log.Int("user_id", 42)}, true},
+		{`user_id == 42`, []log.Field{log.Any("user_id", 42)}, true},
+		{`user_id == 42`, []log.Field{log.Any("user_id", "42")}, true},
+		{`user_id != 42`, []log.Field{log.Int("user_id", 43)}, true},
+		{`user_id != 42`, nil, true},
+		{`user_id == 42`, nil, false},
+		{`ratio == 0.5`, []log.Field{log.Float64("ratio", 0.5)}, true},
+		{`admin`, []log.Field{log.Bool("admin", true)}, true},
+		{`!admin`, []log.Field{log.Bool("admin", true)}, false},
+		{`admin == true`, []log.Field{log.Any("admin", true)}, true},
+		{`!(a == 1 || b == 2) && c`, []log.Field{log.Int("a", 3), log.Int("b", 4), log.String("c", "x")}, true},
+		{`a == 1 || b == 2 && c`, []log.Field{log.Int("a", 1)}, true},
+		{`http.method == "GET"`, []log.Field{log.String("http.method", "GET")}, true},
+		{`latency == "1.5s"`, []log.Field{log.Duration("latency", 1500*time.Millisecond)}, true},
+		{`error == "went wrong"`, []log.Field{log.Err(errors.New("went wrong"))}, true},
+		{`msg == "filtered" && level == "debug"`, nil, true},
+		{`caller == "filter_test.go:54"`, nil, true},
+		{`func == "github.com/Strum355/log_test.Test_Filter.func1"`, nil, true},
+		{`msg == "other"`, nil, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.filter, func(t *testing.T) {
+			var out bytes.Buffer
+			logger, err := log.New(log.WithOutput(&out), log.WithLevel(log.LogInformational))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := logger.SetFilter(test.filter); err != nil {
+				t.Fatal(err)
+			}
+
+			logger.With(test.fields...).Debug("filtered")
+
+			if written := strings.Contains(out.String(), "filtered"); written != test.expected {
+				t.Errorf("expected log point to be written: %v. output: '%s'", test.expected, out.String())
+			}
+		})
+	}
+}
+
+func Test_FilterErrors(t *testing.T) {
+	tests := []struct {
+		filter   string
+		expected string
+	}{
+		{`a ==`, `invalid filter "a ==": unexpected end of expression`},
+		{`a = 1`, `invalid filter "a = 1": unexpected '=' at offset 2`},
+		{`a == 1 &`, `invalid filter "a == 1 &": unexpected '&' at offset 7`},
+		{`(a == 1`, `invalid filter "(a == 1": unexpected end of expression`},
+		{`a == 1)`, `invalid filter "a == 1)": unexpected ")" at offset 6`},
+		{`a == "1`, `invalid filter "a == \"1": unterminated string at offset 5`},
+		{`a == 1.2.3`, `invalid filter "a == 1.2.3": invalid number "1.2.3" at offset 5`},
+		{`a b`, `invalid filter "a b": unexpected "b" at offset 2`},
+		{`&& a`, `invalid filter "&& a": unexpected "&&" at offset 0`},
+	}
+
+	logger, err := log.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range tests {
+		t.Run(test.filter, func(t *testing.T) {
+			if err := logger.SetFilter(test.filter); err == nil || err.Error() != test.expected {
+				t.Errorf("expected error: '%s'. actual error: '%v'", test.expected, err)
+			}
+		})
+	}
+}
+
+func Test_SetFilter(t *testing.T) {
+	defer b.Reset()
+	log.InitSimpleLogger(&log.Config{
+		Output:   b,
+		LogLevel: log.LogError,
+		Filter:   `user_id == "42"`,
+	})
+
+	log.With(log.String("user_id", "42")).Debug("first")
+	log.With(log.String("user_id", "43")).Debug("second")
+
+	if err := log.SetFilter(""); err != nil {
+		t.Fatal(err)
+	}
+	log.With(log.String("user_id", "42")).Debug("third")
+
+	if out := b.String(); !strings.Contains(out, "first") || strings.Contains(out, "second") || strings.Contains(out, "third") {
+		t.Errorf("expected only log points matching the filter to be written: '%s'", out)
+	}
+}
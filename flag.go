@@ -0,0 +1,54 @@
+package log
+
+import (
+	"flag"
+	"io"
+	"os"
+)
+
+// outputFlag is a flag.Value that opens the output it is set to, see
+// openOutput.
+type outputFlag struct {
+	name string
+	w    *io.Writer
+}
+
+func (o *outputFlag) String() string {
+	if o.w == nil {
+		return ""
+	}
+	return o.name
+}
+
+func (o *outputFlag) Set(s string) error {
+	w, err := openOutput(s)
+	if err != nil {
+		return err
+	}
+	o.name, *o.w = s, w
+	return nil
+}
+
+// RegisterFlags defines the -log.level, -log.format, -log.output and
+// -log.caller flags on fs, or flag.CommandLine if fs is nil, and returns the
+// Config they set once fs is parsed. The output is stdout, stderr or the path
+// of a file that is opened for appending. Use the Config with New and
+// WithConfig:
+//
+//	conf := log.RegisterFlags(nil)
+//	flag.Parse()
+//	logger, err := log.New(log.WithConfig(conf))
+func RegisterFlags(fs *flag.FlagSet) *Config {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+
+	conf := &Config{
+		Output: os.Stdout,
+	}
+	fs.Var(&conf.LogLevel, "log.level", "minimum level of log points: debug, info, warn or error")
+	fs.Var(&conf.Format, "log.format", "format of log points: simple, json or logfmt")
+	fs.Var(&outputFlag{name: "stdout", w: &conf.Output}, "log.output", "output of log points: stdout, stderr or a file path")
+	fs.Var(&conf.Caller, "log.caller", "how the caller is reported: basename, off, module or absolute")
+	return conf
+}
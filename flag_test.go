@@ -0,0 +1,82 @@
+package log_test
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Strum355/log"
+)
+
+func Test_RegisterFlags(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "out.log")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	conf := log.RegisterFlags(fs)
+
+	if conf.Output != os.Stdout || conf.LogLevel != log.LogDebug {
+		t.Errorf("expected defaults before parsing: %+v", conf)
+	}
+
+	err := fs.Parse([]string{"-log.level=warn", "-log.format", "JSON", "-log.output", output, "-log.caller=off"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if conf.LogLevel != log.LogWarning || conf.Format != log.FormatJSON || conf.Caller != log.CallerOff {
+		t.Errorf("unexpected config: %+v", conf)
+	}
+
+	f, ok := conf.Output.(*os.File)
+	if !ok || f.Name() != output {
+		t.Fatalf("expected output to be %s: %v", output, conf.Output)
+	}
+	f.Close()
+
+	if got := fs.Lookup("log.level").Value.String(); got != "warn" {
+		t.Errorf("expected level flag value: 'warn'. actual: '%s'", got)
+	}
+}
+
+func Test_RegisterFlagsErrors(t *testing.T) {
+	tests := []struct {
+		arg      string
+		expected string
+	}{
+		{"-log.level=verbose", `invalid value "verbose" for flag -log.level: invalid log level "verbose"`},
+		{"-log.format=xml", `invalid value "xml" for flag -log.format: invalid format "xml"`},
+		{"-log.caller=full", `invalid value "full" for flag -log.caller: invalid caller mode "full"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.arg, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(ioutil.Discard)
+			log.RegisterFlags(fs)
+
+			err := fs.Parse([]string{test.arg})
+			if err == nil || !strings.HasPrefix(err.Error(), test.expected) {
+				t.Errorf("expected error: '%s'. actual error: '%v'", test.expected, err)
+			}
+		})
+	}
+}
+
+func Test_LogLevelString(t *testing.T) {
+	tests := map[log.LogLevel]string{
+		log.LogDebug:         "debug",
+		log.LogInformational: "info",
+		log.LogWarning:       "warn",
+		log.LogError:         "error",
+		log.LogError + 1:     "LogLevel(4)",
+	}
+
+	for level, expected := range tests {
+		if level.String() != expected {
+			t.Errorf("expected: '%s'. actual: '%s'", expected, level.String())
+		}
+	}
+}
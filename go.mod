@@ -1,8 +1,12 @@
 module github.com/Strum355/log
 
-go 1.12
+go 1.20
 
 require (
+	github.com/BurntSushi/toml v1.4.0
 	github.com/opentracing/opentracing-go v1.1.0
-	github.com/stretchr/testify v1.3.0 // indirect
+	github.com/spf13/pflag v1.0.5
+	gopkg.in/yaml.v3 v3.0.1
 )
+
+require github.com/stretchr/testify v1.3.0 // indirect
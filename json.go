@@ -1,32 +1,93 @@
 package log
 
-import "encoding/json"
+import (
+	"sync"
+	"time"
+)
 
-type jsonLogger struct {
-	enc *json.Encoder
-}
+type jsonLogger struct{}
 
 func newJsonLogger() *jsonLogger {
-	return &jsonLogger{
-		json.NewEncoder(config.Output),
-	}
+	return &jsonLogger{}
+}
+
+// jsonEncoder holds the members of a log point while they are sorted. It is
+// pooled so that encoding doesn't allocate in the steady state.
+type jsonEncoder struct {
+	members []Field
+}
+
+var jsonEncoderPool = sync.Pool{
+	New: func() interface{} {
+		return new(jsonEncoder)
+	},
 }
 
-func (j *jsonLogger) createLogPoint(log logPoint) {
-	data := make(map[string]interface{})
-	for k, v := range log.fields {
-		if err, ok := v.(error); ok {
-			data[k] = err.Error()
+func (j *jsonLogger) appendLogPoint(b []byte, c *Config, log logPoint) []byte {
+	enc := jsonEncoderPool.Get().(*jsonEncoder)
+
+	members := enc.members[:0]
+	for _, f := range log.fields {
+		if err, ok := f.error(); ok {
+			members = append(members, String(f.Key, err.Error()))
+			if hasErrorChain(err) {
+				members = append(members, Any(f.Key+"_chain", errorChain(err)))
+			}
 			continue
 		}
-		data[k] = v
+		members = append(members, f)
+	}
+
+	// added last, so they take precedence over fields with the same key
+	if c.Caller != CallerOff {
+		members = append(members,
+			String("_file", log.file),
+			String("_function", log.funcName),
+			Int("_line", log.fileLine),
+		)
+	}
+	members = append(members,
+		String("level", c.prefix(log.level)),
+		String("message", log.msg),
+	)
+	if len(log.stack) > 0 {
+		members = append(members, Any("stacktrace", log.stack))
+	}
+	if !c.DisableTime {
+		members = append(members, c.jsonTime(log.time))
+	}
+
+	// keys are sorted, the same as encoding/json does for maps
+	members = sortFields(members)
+
+	b = append(b, '{')
+	for i, f := range members {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = appendJSONString(b, f.Key)
+		b = append(b, ':')
+		b = appendJSONValue(b, f)
 	}
+	b = append(b, '}', '\n')
 
-	data["_file"] = log.file
-	data["_function"] = log.funcName
-	data["_line"] = log.fileLine
-	data["level"] = getPrefix(log.level)
-	data["message"] = log.msg
-	data["time"] = log.time
-	j.enc.Encode(data)
+	// clear the members so the pool doesn't keep their values alive
+	for i := range members {
+		members[i] = Field{}
+	}
+	enc.members = members[:0]
+	jsonEncoderPool.Put(enc)
+	return b
+}
+
+// jsonTime returns the time field for t. Without a configured format, t is
+// encoded the same way as by time.Time.MarshalJSON.
+func (c *Config) jsonTime(t time.Time) Field {
+	if unix, ok := c.unixTime(t); ok {
+		return Int64("time", unix)
+	}
+	if c.TimeFormat == "" {
+		return Time("time", c.localTime(t))
+	}
+	return String("time", string(c.appendTime(nil, t, "")))
 }
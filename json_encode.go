@@ -0,0 +1,239 @@
+package log
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONField appends the JSON encoding of a typed field's value. Values
+// are encoded the same way encoding/json encodes them.
+func appendJSONField(b []byte, f Field) []byte {
+	switch f.typ {
+	case stringType:
+		return appendJSONString(b, f.str)
+	case intType, durationType:
+		return strconv.AppendInt(b, f.integer, 10)
+	case uintType:
+		return strconv.AppendUint(b, uint64(f.integer), 10)
+	case floatType:
+		return appendJSONFloat(b, math.Float64frombits(uint64(f.integer)))
+	case boolType:
+		return strconv.AppendBool(b, f.integer == 1)
+	case timeType:
+		b = append(b, '"')
+		b = f.time().AppendFormat(b, time.RFC3339Nano)
+		return append(b, '"')
+	case errorType:
+		return appendJSONString(b, f.iface.(error).Error())
+	}
+	return append(b, "null"...)
+}
+
+// appendJSONValue appends the JSON encoding of the value of any field. Common
+// types are encoded directly, while any other types fall back to
+// encoding/json.
+func appendJSONValue(b []byte, f Field) []byte {
+	if f.typ != anyType {
+		return appendJSONField(b, f)
+	}
+
+	switch v := f.iface.(type) {
+	case nil:
+		return append(b, "null"...)
+	case string:
+		return appendJSONString(b, v)
+	case bool:
+		return strconv.AppendBool(b, v)
+	case int:
+		return strconv.AppendInt(b, int64(v), 10)
+	case int8:
+		return strconv.AppendInt(b, int64(v), 10)
+	case int16:
+		return strconv.AppendInt(b, int64(v), 10)
+	case int32:
+		return strconv.AppendInt(b, int64(v), 10)
+	case int64:
+		return strconv.AppendInt(b, v, 10)
+	case uint:
+		return strconv.AppendUint(b, uint64(v), 10)
+	case uint8:
+		return strconv.AppendUint(b, uint64(v), 10)
+	case uint16:
+		return strconv.AppendUint(b, uint64(v), 10)
+	case uint32:
+		return strconv.AppendUint(b, uint64(v), 10)
+	case uint64:
+		return strconv.AppendUint(b, v, 10)
+	case uintptr:
+		return strconv.AppendUint(b, uint64(v), 10)
+	case float32:
+		return appendJSONFloat32(b, v)
+	case float64:
+		return appendJSONFloat(b, v)
+	case time.Duration:
+		return strconv.AppendInt(b, int64(v), 10)
+	case time.Time:
+		// time.Time.MarshalJSON fails for years outside of [0,9999]
+		if y := v.Year(); y >= 0 && y <= 9999 {
+			b = append(b, '"')
+			b = v.AppendFormat(b, time.RFC3339Nano)
+			return append(b, '"')
+		}
+	case error:
+		return appendJSONString(b, v.Error())
+	case []stackFrame:
+		return appendJSONStack(b, v)
+	case []errorLayer:
+		return appendJSONErrorChain(b, v)
+	}
+
+	data, err := json.Marshal(f.iface)
+	if err != nil {
+		return appendJSONString(b, "!ERROR: "+err.Error())
+	}
+	return append(b, data...)
+}
+
+func appendJSONStack(b []byte, stack []stackFrame) []byte {
+	b = append(b, '[')
+	for i, f := range stack {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = append(b, `{"function":`...)
+		b = appendJSONString(b, f.Function)
+		b = append(b, `,"file":`...)
+		b = appendJSONString(b, f.File)
+		b = append(b, `,"line":`...)
+		b = strconv.AppendInt(b, int64(f.Line), 10)
+		b = append(b, '}')
+	}
+	return append(b, ']')
+}
+
+func appendJSONErrorChain(b []byte, chain []errorLayer) []byte {
+	b = append(b, '[')
+	for i, layer := range chain {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = append(b, `{"message":`...)
+		b = appendJSONString(b, layer.Message)
+		b = append(b, `,"type":`...)
+		b = appendJSONString(b, layer.Type)
+		if len(layer.Errors) > 0 {
+			b = append(b, `,"errors":[`...)
+			for i, chain := range layer.Errors {
+				if i > 0 {
+					b = append(b, ',')
+				}
+				b = appendJSONErrorChain(b, chain)
+			}
+			b = append(b, ']')
+		}
+		b = append(b, '}')
+	}
+	return append(b, ']')
+}
+
+// appendJSONString appends s as a JSON string, escaped the same way
+// encoding/json escapes strings with HTML escaping enabled.
+func appendJSONString(b []byte, s string) []byte {
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+			b = append(b, s[start:i]...)
+			switch c {
+			case '\\', '"':
+				b = append(b, '\\', c)
+			case '\b':
+				b = append(b, '\\', 'b')
+			case '\f':
+				b = append(b, '\\', 'f')
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\r':
+				b = append(b, '\\', 'r')
+			case '\t':
+				b = append(b, '\\', 't')
+			default:
+				b = append(b, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b = append(b, s[start:i]...)
+			b = append(b, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		// U+2028 and U+2029 are valid JSON but not valid JavaScript
+		if r == '\u2028' || r == '\u2029' {
+			b = append(b, s[start:i]...)
+			b = append(b, '\\', 'u', '2', '0', '2', hexDigits[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b = append(b, s[start:]...)
+	return append(b, '"')
+}
+
+// appendJSONFloat32 appends f formatted the same way encoding/json formats
+// float32 values.
+func appendJSONFloat32(b []byte, f float32) []byte {
+	f64 := float64(f)
+	if math.IsNaN(f64) || math.IsInf(f64, 0) {
+		return appendJSONString(b, strconv.FormatFloat(f64, 'g', -1, 32))
+	}
+
+	format := byte('f')
+	if abs := float32(math.Abs(f64)); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	return cleanExponent(strconv.AppendFloat(b, f64, format, -1, 32), format)
+}
+
+// appendJSONFloat appends f formatted the same way encoding/json formats
+// float64 values. As JSON can't represent NaN and infinities, they are
+// written as strings.
+func appendJSONFloat(b []byte, f float64) []byte {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return appendJSONString(b, strconv.FormatFloat(f, 'g', -1, 64))
+	}
+
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	return cleanExponent(strconv.AppendFloat(b, f, format, -1, 64), format)
+}
+
+func cleanExponent(b []byte, format byte) []byte {
+	if format == 'e' {
+		// clean up e-09 to e-9
+		n := len(b)
+		if n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	return b
+}
@@ -0,0 +1,128 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_AppendJSONString(t *testing.T) {
+	tests := []string{
+		"",
+		"plain",
+		`quotes " and \ backslashes`,
+		"control \x00 \x01 \x1f \b \f \n \r \t",
+		"<html> & stuff",
+		"unicode ✓ \u2028 \u2029",
+		"invalid \xff utf8",
+	}
+
+	for _, s := range tests {
+		expected, _ := json.Marshal(s)
+		if actual := appendJSONString(nil, s); string(actual) != string(expected) {
+			t.Errorf("expected: %s. actual: %s", expected, actual)
+		}
+	}
+}
+
+func Test_AppendJSONFloat(t *testing.T) {
+	tests := []float64{0, 1, -1.5, 1e-7, 123456789, 1e21, 1e-300, math.MaxFloat64, math.SmallestNonzeroFloat64}
+
+	for _, f := range tests {
+		expected, _ := json.Marshal(f)
+		if actual := appendJSONFloat(nil, f); string(actual) != string(expected) {
+			t.Errorf("expected: %s. actual: %s", expected, actual)
+		}
+	}
+
+	if actual := appendJSONFloat(nil, math.NaN()); string(actual) != `"NaN"` {
+		t.Errorf("expected NaN to be written as a string: %s", actual)
+	}
+}
+
+// referenceJSON encodes a log point the way the JSON logger did before it used
+// its own encoder, using a map and encoding/json.
+func referenceJSON(log logPoint) string {
+	data := make(map[string]interface{})
+	for _, f := range log.fields {
+		if err, ok := f.error(); ok {
+			data[f.Key] = err.Error()
+			if hasErrorChain(err) {
+				data[f.Key+"_chain"] = errorChain(err)
+			}
+			continue
+		}
+		data[f.Key] = f.value()
+	}
+
+	data["_file"] = log.file
+	data["_function"] = log.funcName
+	data["_line"] = log.fileLine
+	data["level"] = loadConfig().prefix(log.level)
+	data["message"] = log.msg
+	if log.stack != nil {
+		data["stacktrace"] = log.stack
+	}
+	data["time"] = log.time
+
+	var b strings.Builder
+	json.NewEncoder(&b).Encode(data)
+	return b.String()
+}
+
+type textMarshaler struct{}
+
+func (textMarshaler) MarshalText() ([]byte, error) {
+	return []byte("<text>"), nil
+}
+
+func Test_JSONEncoderMatchesEncodingJSON(t *testing.T) {
+	InitJSONLogger(&Config{})
+
+	now := time.Now()
+	fields := Fields{
+		"string":   "<b>\"quoted\"</b>\n",
+		"int":      -42,
+		"int8":     int8(-8),
+		"uint16":   uint16(16),
+		"uint64":   uint64(math.MaxUint64),
+		"float32":  float32(0.1),
+		"float64":  1e-9,
+		"bool":     false,
+		"nil":      nil,
+		"duration": 1500 * time.Millisecond,
+		"time":     now,
+		"past":     time.Date(1, 2, 3, 4, 5, 6, 7, time.FixedZone("X", 3600)),
+		"error":    fmt.Errorf("wrapped: %w", errors.New("<inner>")),
+		"slice":    []interface{}{1, "two", nil},
+		"map":      map[string]int{"b": 2, "a": 1},
+		"struct":   struct{ A, b int }{1, 2},
+		"bytes":    []byte("bytes"),
+		"text":     textMarshaler{},
+		"secret":   Secret("hunter2"),
+		"level":    "overridden",
+	}
+
+	for _, stack := range [][]stackFrame{nil, {{"main.main", "/main.go", 1}, {"runtime.main", "/proc.go", 2}}} {
+		point := logPoint{
+			level:    LogWarning,
+			fileLine: 12,
+			file:     "file.go",
+			funcName: "func1",
+			msg:      "message with <html> &  ",
+			fields:   fields.fieldSlice(),
+			time:     now,
+			stack:    stack,
+		}
+
+		actual := loadConfig().logger.appendLogPoint(nil, loadConfig(), point)
+
+		if expected := referenceJSON(point); string(actual) != expected {
+			t.Errorf("expected: %s\nactual:   %s", expected, actual)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// fileConfig is the serialized form of a Config, as read from configuration
+// files and environment variables.
+type fileConfig struct {
+	Level      LogLevel            `json:"level" yaml:"level" toml:"level"`
+	Format     Format              `json:"format" yaml:"format" toml:"format"`
+	Output     string              `json:"output" yaml:"output" toml:"output"`
+	Caller     CallerMode          `json:"caller" yaml:"caller" toml:"caller"`
+	TimeFormat string              `json:"time_format" yaml:"time_format" toml:"time_format"`
+	Color      bool                `json:"color" yaml:"color" toml:"color"`
+	StdErr     bool                `json:"stderr" yaml:"stderr" toml:"stderr"`
+	Filter     string              `json:"filter" yaml:"filter" toml:"filter"`
+	Packages   map[string]LogLevel `json:"packages" yaml:"packages" toml:"packages"`
+	Sinks      []sinkConfig        `json:"sinks" yaml:"sinks" toml:"sinks"`
+	Sampling   *samplingConfig     `json:"sampling" yaml:"sampling" toml:"sampling"`
+}
+
+type sinkConfig struct {
+	Output string   `json:"output" yaml:"output" toml:"output"`
+	Format Format   `json:"format" yaml:"format" toml:"format"`
+	Level  LogLevel `json:"level" yaml:"level" toml:"level"`
+}
+
+type samplingConfig struct {
+	Initial    int      `json:"initial" yaml:"initial" toml:"initial"`
+	Thereafter int      `json:"thereafter" yaml:"thereafter" toml:"thereafter"`
+	Tick       duration `json:"tick" yaml:"tick" toml:"tick"`
+}
+
+// duration is a time.Duration written as text e.g. "1s" in every format.
+type duration time.Duration
+
+func (d duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return fmt.Errorf("invalid duration %q", text)
+	}
+	*d = duration(v)
+	return nil
+}
+
+// ConfigFromFile reads a Config from a JSON, YAML or TOML file, depending on
+// its extension. For example, in YAML:
+//
+//	level: info
+//	format: json
+//	output: stdout
+//	caller: module
+//	time_format: 2006-01-02T15:04:05Z07:00
+//	color: false
+//	stderr: true
+//	filter: user_id == "42"
+//	packages:
+//	  github.com/org/service/db: debug
+//	sinks:
+//	  - output: /var/log/service.log
+//	    format: logfmt
+//	    level: warn
+//	sampling:
+//	  initial: 100
+//	  thereafter: 10
+//	  tick: 1s
+//
+// Every setting is optional. Outputs are stdout, stderr or the path of a file
+// that is opened for appending. Use the Config with New and WithConfig, or
+// with one of the Init functions, which ignore its Format.
+func ConfigFromFile(path string) (*Config, error) {
+	fc, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	conf, err := fc.config(openOutput, closeOutput)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return conf, nil
+}
+
+// readConfigFile decodes the configuration file at path according to its
+// extension.
+func readConfigFile(path string) (*fileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fc := new(fileConfig)
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(fc)
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err = dec.Decode(fc); err == io.EOF {
+			// empty file
+			err = nil
+		}
+	case ".toml":
+		var md toml.MetaData
+		md, err = toml.Decode(string(data), fc)
+		if undecoded := md.Undecoded(); err == nil && len(undecoded) > 0 {
+			err = fmt.Errorf("unknown setting %q", undecoded[0].String())
+		}
+	default:
+		return nil, fmt.Errorf("%s: unsupported config file extension %q, expected .json, .yaml, .yml or .toml", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return fc, nil
+}
+
+// ConfigFromEnv reads a Config from the environment variables named prefix
+// followed by:
+//
+//	_LEVEL           debug, info, warn or error
+//	_FORMAT          simple, json or logfmt
+//	_OUTPUT          stdout, stderr or the path of a file opened for appending
+//	_CALLER          basename, off, module or absolute
+//	_TIME_FORMAT     the layout of timestamps
+//	_COLOR           true or false
+//	_STDERR          true or false, see Config.UseStdErr
+//	_FILTER          a filter expression, see Config.Filter
+//	_PACKAGE_LEVELS  comma separated package=level pairs, see Config.PackageLevels
+//
+// e.g. LOG_LEVEL for the prefix LOG. Unset variables keep their defaults.
+func ConfigFromEnv(prefix string) (*Config, error) {
+	var fc fileConfig
+
+	vars := []struct {
+		name  string
+		parse func(string) error
+	}{
+		{"_LEVEL", func(v string) error { return fc.Level.UnmarshalText([]byte(v)) }},
+		{"_FORMAT", func(v string) error { return fc.Format.UnmarshalText([]byte(v)) }},
+		{"_OUTPUT", func(v string) error { fc.Output = v; return nil }},
+		{"_CALLER", func(v string) error { return fc.Caller.UnmarshalText([]byte(v)) }},
+		{"_TIME_FORMAT", func(v string) error { fc.TimeFormat = v; return nil }},
+		{"_COLOR", func(v string) (err error) { fc.Color, err = parseBool(v); return }},
+		{"_STDERR", func(v string) (err error) { fc.StdErr, err = parseBool(v); return }},
+		{"_FILTER", func(v string) error { fc.Filter = v; return nil }},
+		{"_PACKAGE_LEVELS", func(v string) (err error) { fc.Packages, err = parsePackageLevels(v); return }},
+	}
+
+	for _, v := range vars {
+		name := prefix + v.name
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := v.parse(value); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return fc.config(openOutput, closeOutput)
+}
+
+func parseBool(s string) (bool, error) {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, fmt.Errorf("invalid boolean %q", s)
+	}
+	return b, nil
+}
+
+// parsePackageLevels parses comma separated package=level pairs.
+func parsePackageLevels(s string) (map[string]LogLevel, error) {
+	levels := make(map[string]LogLevel)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		i := strings.LastIndex(pair, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid package level %q, expected package=level", pair)
+		}
+
+		var level LogLevel
+		if err := level.UnmarshalText([]byte(pair[i+1:])); err != nil {
+			return nil, err
+		}
+		levels[pair[:i]] = level
+	}
+	return levels, nil
+}
+
+// config returns the Config described by fc, using open to open its outputs.
+// If it fails, release is called with every output already opened.
+func (fc *fileConfig) config(open func(string) (io.Writer, error), release func(io.Writer)) (*Config, error) {
+	output, err := open(fc.Output)
+	if err != nil {
+		return nil, err
+	}
+
+	conf := &Config{
+		LogLevel:      fc.Level,
+		Format:        fc.Format,
+		Output:        output,
+		Caller:        fc.Caller,
+		TimeFormat:    fc.TimeFormat,
+		Color:         fc.Color,
+		UseStdErr:     fc.StdErr,
+		Filter:        fc.Filter,
+		PackageLevels: fc.Packages,
+	}
+	if s := fc.Sampling; s != nil {
+		conf.Sampling = &Sampling{
+			Initial:    s.Initial,
+			Thereafter: s.Thereafter,
+			Tick:       time.Duration(s.Tick),
+		}
+	}
+
+	fail := func(err error) (*Config, error) {
+		release(conf.Output)
+		for _, sink := range conf.Sinks {
+			release(sink.Output)
+		}
+		return nil, err
+	}
+
+	for i, sink := range fc.Sinks {
+		if sink.Output == "" {
+			return fail(fmt.Errorf("output of sink %d must be set", i))
+		}
+		output, err := open(sink.Output)
+		if err != nil {
+			return fail(fmt.Errorf("sink %d: %w", i, err))
+		}
+		conf.Sinks = append(conf.Sinks, Sink{
+			Output:   output,
+			Format:   sink.Format,
+			LogLevel: sink.Level,
+		})
+	}
+	return conf, nil
+}
+
+// openOutput returns stdout, stderr or the file at path opened for appending.
+func openOutput(path string) (io.Writer, error) {
+	switch path {
+	case "", "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// closeOutput closes an output opened by openOutput, unless it is stdout or
+// stderr.
+func closeOutput(w io.Writer) {
+	if f, ok := w.(*os.File); ok && f != os.Stdout && f != os.Stderr {
+		f.Close()
+	}
+}
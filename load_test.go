@@ -0,0 +1,256 @@
+package log_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Strum355/log"
+)
+
+func Test_ConfigFromFile(t *testing.T) {
+	dir := t.TempDir()
+	sinkPath := filepath.Join(dir, "sink.log")
+
+	files := map[string]string{
+		"config.json": `{
+			"level": "warn",
+			"format": "json",
+			"output": "stderr",
+			"caller": "module",
+			"time_format": "15:04",
+			"packages": {"github.com/org/service/db": "debug"},
+			"sinks": [{"output": "` + sinkPath + `", "format": "logfmt", "level": "error"}],
+			"sampling": {"initial": 100, "thereafter": 10, "tick": "2s"}
+		}`,
+		"config.yaml": `
+level: warn
+format: json
+output: stderr
+caller: module
+time_format: "15:04"
+packages:
+  github.com/org/service/db: debug
+sinks:
+  - output: ` + sinkPath + `
+    format: logfmt
+    level: error
+sampling:
+  initial: 100
+  thereafter: 10
+  tick: 2s
+`,
+		"config.toml": `
+level = "warn"
+format = "json"
+output = "stderr"
+caller = "module"
+time_format = "15:04"
+
+[packages]
+"github.com/org/service/db" = "debug"
+
+[[sinks]]
+output = "` + sinkPath + `"
+format = "logfmt"
+level = "error"
+
+[sampling]
+initial = 100
+thereafter = 10
+tick = "2s"
+`,
+	}
+
+	for name, content := range files {
+		t.Run(filepath.Ext(name)[1:], func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			conf, err := log.ConfigFromFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if conf.LogLevel != log.LogWarning || conf.Format != log.FormatJSON || conf.Output != os.Stderr ||
+				conf.Caller != log.CallerModule || conf.TimeFormat != "15:04" {
+				t.Errorf("unexpected config: %+v", conf)
+			}
+
+			if conf.PackageLevels["github.com/org/service/db"] != log.LogDebug || len(conf.PackageLevels) != 1 {
+				t.Errorf("unexpected package levels: %v", conf.PackageLevels)
+			}
+
+			if s := conf.Sampling; s == nil || s.Initial != 100 || s.Thereafter != 10 || s.Tick != 2*time.Second {
+				t.Errorf("unexpected sampling: %+v", s)
+			}
+
+			if len(conf.Sinks) != 1 || conf.Sinks[0].Format != log.FormatLogfmt || conf.Sinks[0].LogLevel != log.LogError {
+				t.Fatalf("unexpected sinks: %+v", conf.Sinks)
+			}
+			if f, ok := conf.Sinks[0].Output.(*os.File); !ok || f.Name() != sinkPath {
+				t.Errorf("expected sink output to be %s: %v", sinkPath, conf.Sinks[0].Output)
+			} else {
+				f.Close()
+			}
+		})
+	}
+}
+
+func Test_ConfigFromFileErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "level.json",
+			content:  `{"level": "verbose"}`,
+			expected: `invalid log level "verbose", expected one of debug, info, warn or error`,
+		},
+		{
+			name:     "format.yaml",
+			content:  `format: xml`,
+			expected: `invalid format "xml", expected one of simple, json or logfmt`,
+		},
+		{
+			name:     "unknown.toml",
+			content:  `levle = "info"`,
+			expected: `unknown setting "levle"`,
+		},
+		{
+			name:     "unknown.json",
+			content:  `{"levle": "info"}`,
+			expected: `unknown field "levle"`,
+		},
+		{
+			name:     "sink.yaml",
+			content:  "sinks:\n  - format: json",
+			expected: "output of sink 0 must be set",
+		},
+		{
+			name:     "sampling.yaml",
+			content:  "sampling:\n  tick: often",
+			expected: `invalid duration "often"`,
+		},
+		{
+			name:     "config.ini",
+			expected: `unsupported config file extension ".ini"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(dir, test.name)
+			if err := os.WriteFile(path, []byte(test.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := log.ConfigFromFile(path)
+			if err == nil || !strings.HasPrefix(err.Error(), path+": ") || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("expected error containing: '%s'. actual error: '%v'", test.expected, err)
+			}
+		})
+	}
+}
+
+func Test_ConfigFromEnv(t *testing.T) {
+	t.Setenv("APP_LOG_LEVEL", "INFO")
+	t.Setenv("APP_LOG_FORMAT", "logfmt")
+	t.Setenv("APP_LOG_CALLER", "off")
+	t.Setenv("APP_LOG_COLOR", "true")
+	t.Setenv("APP_LOG_PACKAGE_LEVELS", "github.com/org/a=debug, github.com/org/b=error")
+
+	conf, err := log.ConfigFromEnv("APP_LOG")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if conf.LogLevel != log.LogInformational || conf.Format != log.FormatLogfmt || conf.Caller != log.CallerOff ||
+		!conf.Color || conf.Output != os.Stdout {
+		t.Errorf("unexpected config: %+v", conf)
+	}
+
+	if conf.PackageLevels["github.com/org/a"] != log.LogDebug || conf.PackageLevels["github.com/org/b"] != log.LogError {
+		t.Errorf("unexpected package levels: %v", conf.PackageLevels)
+	}
+
+	t.Setenv("APP_LOG_STDERR", "maybe")
+	if _, err := log.ConfigFromEnv("APP_LOG"); err == nil || err.Error() != `APP_LOG_STDERR: invalid boolean "maybe"` {
+		t.Errorf("expected invalid boolean error: %v", err)
+	}
+}
+
+func Test_PackageLevels(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := log.New(log.WithConfig(&log.Config{
+		Output:   &out,
+		LogLevel: log.LogError,
+		PackageLevels: map[string]log.LogLevel{
+			"github.com/Strum355":          log.LogWarning,
+			"github.com/Strum355/log_test": log.LogDebug,
+			"github.com/Strum355/log_tes":  log.LogError,
+		},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger.Debug("debug")
+
+	if !strings.Contains(out.String(), "debug") {
+		t.Errorf("expected the most specific package level to apply: '%s'", out.String())
+	}
+}
+
+func Test_Sinks(t *testing.T) {
+	var out, sink bytes.Buffer
+	logger, err := log.New(log.WithConfig(&log.Config{
+		Output:   &out,
+		LogLevel: log.LogInformational,
+		Sinks: []log.Sink{
+			{Output: &sink, Format: log.FormatJSON, LogLevel: log.LogWarning},
+		},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.With(log.String("key", "value")).Warn("warn")
+
+	if strings.Contains(out.String(), "debug") || !strings.Contains(out.String(), "info") || !strings.Contains(out.String(), "warn\n\tkey='value'") {
+		t.Errorf("unexpected output: '%s'", out.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(sink.String()), "\n")
+	if len(lines) != 1 || !strings.Contains(lines[0], `"message":"warn"`) || !strings.Contains(lines[0], `"key":"value"`) {
+		t.Errorf("unexpected sink output: '%s'", sink.String())
+	}
+
+	if _, err := log.New(log.WithConfig(&log.Config{Sinks: []log.Sink{{}}})); err == nil || err.Error() != "output of sink 0 must not be nil" {
+		t.Errorf("expected nil sink output error: %v", err)
+	}
+}
+
+func Test_LogLevelText(t *testing.T) {
+	for _, level := range []log.LogLevel{log.LogDebug, log.LogInformational, log.LogWarning, log.LogError} {
+		text, err := level.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var parsed log.LogLevel
+		if err := parsed.UnmarshalText(text); err != nil || parsed != level {
+			t.Errorf("expected %s to round trip: %v %v", text, parsed, err)
+		}
+	}
+}
@@ -1,10 +1,14 @@
 package log
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"regexp"
+	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -19,19 +23,66 @@ const (
 	LogError
 )
 
+var levelNames = [...]string{
+	LogDebug:         "debug",
+	LogInformational: "info",
+	LogWarning:       "warn",
+	LogError:         "error",
+}
+
+func (l LogLevel) String() string {
+	if l > LogError {
+		return fmt.Sprintf("LogLevel(%d)", l)
+	}
+	return levelNames[l]
+}
+
+// Set implements flag.Value, see UnmarshalText.
+func (l *LogLevel) Set(s string) error {
+	return l.UnmarshalText([]byte(s))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (l LogLevel) MarshalText() ([]byte, error) {
+	if l > LogError {
+		return nil, fmt.Errorf("invalid log level %d", l)
+	}
+	return []byte(levelNames[l]), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. It accepts the names
+// debug, info, warn and error, case-insensitively, as well as informational
+// and warning.
+func (l *LogLevel) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "debug":
+		*l = LogDebug
+	case "info", "informational":
+		*l = LogInformational
+	case "warn", "warning":
+		*l = LogWarning
+	case "error":
+		*l = LogError
+	default:
+		return fmt.Errorf("invalid log level %q, expected one of debug, info, warn or error", text)
+	}
+	return nil
+}
+
 type logger interface {
-	createLogPoint(log logPoint)
+	// appendLogPoint appends the formatted log point to b.
+	appendLogPoint(b []byte, c *Config, log logPoint) []byte
 }
 
 type logPoint struct {
-	b        *strings.Builder
 	level    LogLevel
 	fileLine int
 	file     string
 	funcName string
 	msg      string
-	fields   Fields
+	fields   []Field
 	time     time.Time
+	stack    []stackFrame
 }
 
 type Config struct {
@@ -41,36 +92,254 @@ type Config struct {
 	DebugPrefix string
 	LogLevel    LogLevel
 	Output      io.Writer
+	// Format is the format of log points written by a Logger created with
+	// New. The Init functions ignore it.
+	Format Format
 	// Will print error level to StdErr
 	// UseStdErr is ignored if Output != os.Stdout
-	UseStdErr    bool
-	logger       logger
-	levelPadding int
+	UseStdErr bool
+	// Color enables colored output for the simple logger. It is disabled
+	// automatically if Output isn't a terminal or NO_COLOR is set.
+	Color bool
+	// TimeFormat is the layout used to format timestamps, or one of
+	// TimeFormatUnix, TimeFormatUnixMilli or TimeFormatUnixNano. Every
+	// formatter has its own default.
+	TimeFormat string
+	// TimeLocation, if set, is the location timestamps are converted to
+	// before formatting, e.g. time.UTC.
+	TimeLocation *time.Location
+	// DisableTime omits the timestamp from log points, which is useful when
+	// running under e.g. systemd which adds its own.
+	DisableTime bool
+	// Caller controls how the file of the calling function is reported.
+	Caller CallerMode
+	// FullFunctionName reports the package qualified name of the calling
+	// function e.g. pkg.(*Type).Method.func1 instead of only the last
+	// element e.g. func1.
+	FullFunctionName bool
+	// EnableStackTrace attaches the stack trace of the calling goroutine to
+	// log points at or above StackTraceLevel. If a logged error carries its
+	// own stack trace, that one is used instead.
+	EnableStackTrace bool
+	StackTraceLevel  LogLevel
+	// OuterErrorFieldsWin gives Fields carried by outer errors precedence
+	// over those carried by the errors they wrap, see WrapError. By default
+	// the innermost error wins.
+	OuterErrorFieldsWin bool
+	// RedactKeys masks the values of fields whose key matches any of the
+	// case-insensitive glob patterns e.g. "*password*", see path.Match. This
+	// includes the fields rendered in the messages of errors created with
+	// Entry.Err.
+	RedactKeys []string
+	// RedactPatterns masks every match of the patterns in field values as
+	// they are written, including the messages of errors and their chains,
+	// see RedactBearerTokens and RedactCardNumbers.
+	RedactPatterns []*regexp.Regexp
+	redactKeys     []string
+	// Pseudonymizer, if set, replaces the values of identifying fields
+	// with pseudonyms before they are logged.
+	Pseudonymizer *Pseudonymizer
+	// PackageLevels overrides LogLevel for log points from the packages with
+	// the given import paths and the packages below them, e.g.
+	// "github.com/org/service/db". The most specific path wins. Sinks aren't
+	// affected.
+	PackageLevels map[string]LogLevel
+	// Sinks are additional outputs log points are written to, each with its
+	// own format and level.
+	Sinks []Sink
+	// Filter is an expression such as `user_id == "42" && region == "eu"`.
+	// Log points matching it are written to Output regardless of their level,
+	// to enable debug log points for only some of them. Fields are matched
+	// before they are redacted or pseudonymized. See SetFilter for changing
+	// it at runtime.
+	Filter string
+	filter *filter
+	// Sampling, if set, limits the number of repetitive log points written
+	// to Output and the sinks.
+	Sampling      *Sampling
+	sampler       *sampler
+	color         bool
+	logger        logger
+	levelPadding  int
+	packageLevels []packageLevel
+	sinks         []*Config
+	// minLevel is the lowest level written to any output.
+	minLevel   LogLevel
+	outputLock *writerLock
+	stderrLock *writerLock
+	// writes tracks the log points being written with the configuration and
+	// copies of it with a different level or filter, see retire.
+	writes *activeWrites
+}
+
+// Sink is an additional output of a Config. Every other setting is shared
+// with the Config.
+type Sink struct {
+	Output   io.Writer
+	Format   Format
+	LogLevel LogLevel
+}
+
+type packageLevel struct {
+	path  string
+	level LogLevel
+}
+
+// globalConfig holds the active *Config. It is replaced atomically by the Init
+// functions, so that every log point sees a consistent configuration.
+var globalConfig atomic.Pointer[Config]
+
+func loadConfig() *Config {
+	return globalConfig.Load()
+}
+
+// SetLevel atomically changes the minimum level of log points written by the
+// global logger. It fails if the logger isn't initialized.
+func SetLevel(level LogLevel) error {
+	_, _, err := updateLevel(&globalConfig, func(LogLevel) LogLevel { return level })
+	return err
+}
+
+// SetFilter atomically changes the filter of the global logger, see
+// Config.Filter. An empty expression removes the filter. It fails if the
+// logger isn't initialized or expr is invalid.
+func SetFilter(expr string) error {
+	return updateFilter(&globalConfig, expr)
+}
+
+// updateLevel atomically replaces the level of the configuration in p with
+// the result of f, returning the previous and the new level.
+func updateLevel(p *atomic.Pointer[Config], f func(LogLevel) LogLevel) (from, to LogLevel, err error) {
+	err = updateConfig(p, func(c *Config) (*Config, error) {
+		from, to = c.LogLevel, f(c.LogLevel)
+		if to > LogError {
+			return nil, fmt.Errorf("invalid log level %d", to)
+		}
+
+		next := *c
+		next.LogLevel = to
+		next.setMinLevel()
+		return &next, nil
+	})
+	return from, to, err
+}
+
+// updateFilter atomically replaces the filter of the configuration in p.
+func updateFilter(p *atomic.Pointer[Config], expr string) error {
+	var f *filter
+	if expr != "" {
+		var err error
+		if f, err = parseFilter(expr); err != nil {
+			return err
+		}
+	}
+
+	return updateConfig(p, func(c *Config) (*Config, error) {
+		next := *c
+		next.Filter, next.filter = expr, f
+		next.setMinLevel()
+		return &next, nil
+	})
 }
 
-var config *Config
+// updateConfig atomically replaces the configuration in p with a modified
+// copy returned by f, which may be called multiple times.
+func updateConfig(p *atomic.Pointer[Config], f func(*Config) (*Config, error)) error {
+	for {
+		c := p.Load()
+		if c == nil {
+			return errors.New("logger is not initialized")
+		}
 
+		next, err := f(c)
+		if err != nil {
+			return err
+		}
+		if p.CompareAndSwap(c, next) {
+			return nil
+		}
+	}
+}
+
+// InitJSONLogger initializes the global logger to write JSON log points. conf
+// is copied, so it can't be used to change the configuration afterwards.
 func InitJSONLogger(conf *Config) {
-	setDefaults(conf)
-	config = conf
-	config.logger = newJsonLogger()
+	initLogger(conf, FormatJSON)
 }
 
+// InitSimpleLogger initializes the global logger to write human readable log
+// points. conf is copied, so it can't be used to change the configuration
+// afterwards.
 func InitSimpleLogger(conf *Config) {
-	setDefaults(conf)
-	setLevelPadding(conf)
-	config = conf
-	config.logger = newSimpleLogger()
+	initLogger(conf, FormatSimple)
+}
 
+// InitLogfmtLogger initializes the global logger to write logfmt log points.
+// conf is copied, so it can't be used to change the configuration afterwards.
+func InitLogfmtLogger(conf *Config) {
+	initLogger(conf, FormatLogfmt)
 }
 
-func setDefaults(conf *Config) {
-	if conf == nil {
-		conf = new(Config)
+func initLogger(conf *Config, format Format) {
+	c, err := newConfig(conf, format)
+	if err != nil {
+		panic(err.Error())
+	}
+	storeConfig(c)
+}
+
+// newConfig returns a copy of conf with defaults set, that writes log points
+// in the given format.
+func newConfig(conf *Config, format Format) (*Config, error) {
+	c := new(Config)
+	if conf != nil {
+		*c = *conf
+	}
+	if err := setDefaults(c); err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatSimple:
+		setLevelPadding(c)
+		c.color = c.Color && useColor(c.Output)
+		c.logger = newSimpleLogger()
+	case FormatJSON:
+		c.logger = newJsonLogger()
+	case FormatLogfmt:
+		c.logger = newLogfmtLogger()
+	default:
+		return nil, fmt.Errorf("invalid format %d", format)
 	}
 
+	if err := setPackageLevels(c); err != nil {
+		return nil, err
+	}
+	if err := setSinks(c); err != nil {
+		return nil, err
+	}
+	if err := setSampler(c); err != nil {
+		return nil, err
+	}
+
+	c.filter = nil
+	if c.Filter != "" {
+		var err error
+		if c.filter, err = parseFilter(c.Filter); err != nil {
+			return nil, err
+		}
+	}
+	c.setMinLevel()
+
+	c.outputLock = acquireWriterLock(c.Output)
+	c.stderrLock = acquireWriterLock(os.Stderr)
+	c.writes = new(activeWrites)
+	return c, nil
+}
+
+func setDefaults(conf *Config) error {
 	if conf.LogLevel > LogError {
-		panic(fmt.Sprintf("invalid log level %d", conf.LogLevel))
+		return fmt.Errorf("invalid log level %d", conf.LogLevel)
 	}
 
 	if conf.ErrorPrefix == "" {
@@ -87,6 +356,13 @@ func setDefaults(conf *Config) {
 		conf.DebugPrefix = "DEBUG"
 	}
 
+	if err := setRedactKeys(conf); err != nil {
+		return err
+	}
+	if err := setPseudonymKeys(conf); err != nil {
+		return err
+	}
+
 	if conf.UseStdErr && conf.Output != os.Stdout {
 		conf.UseStdErr = false
 	}
@@ -94,6 +370,113 @@ func setDefaults(conf *Config) {
 	if conf.Output == nil {
 		conf.Output = os.Stdout
 	}
+	return nil
+}
+
+// setPackageLevels validates the package level overrides and sorts them most
+// specific first.
+func setPackageLevels(conf *Config) error {
+	conf.packageLevels = nil
+	for path, level := range conf.PackageLevels {
+		if level > LogError {
+			return fmt.Errorf("invalid log level %d for package %q", level, path)
+		}
+		if path == "" {
+			return errors.New("package path must not be empty")
+		}
+		conf.packageLevels = append(conf.packageLevels, packageLevel{path: path, level: level})
+	}
+
+	sort.Slice(conf.packageLevels, func(i, j int) bool {
+		return len(conf.packageLevels[i].path) > len(conf.packageLevels[j].path)
+	})
+	return nil
+}
+
+// setSinks creates the configurations of the sinks, which share every setting
+// other than the output, format and level.
+func setSinks(conf *Config) error {
+	conf.sinks = make([]*Config, 0, len(conf.Sinks))
+	for i, sink := range conf.Sinks {
+		if sink.Output == nil {
+			return fmt.Errorf("output of sink %d must not be nil", i)
+		}
+
+		sc := *conf
+		sc.Output = sink.Output
+		sc.LogLevel = sink.LogLevel
+		sc.UseStdErr = false
+		sc.PackageLevels = nil
+		sc.Sinks = nil
+		sc.Filter = ""
+		sc.Sampling = nil
+		c, err := newConfig(&sc, sink.Format)
+		if err != nil {
+			return fmt.Errorf("sink %d: %w", i, err)
+		}
+
+		conf.sinks = append(conf.sinks, c)
+	}
+	return nil
+}
+
+// setMinLevel sets the lowest level written to any output. As log points at
+// any level can match the filter, it is LogDebug if there is one.
+func (c *Config) setMinLevel() {
+	c.minLevel = c.LogLevel
+	if c.filter != nil {
+		c.minLevel = LogDebug
+	}
+	for _, pl := range c.packageLevels {
+		if pl.level < c.minLevel {
+			c.minLevel = pl.level
+		}
+	}
+	for _, sink := range c.sinks {
+		if sink.LogLevel < c.minLevel {
+			c.minLevel = sink.LogLevel
+		}
+	}
+}
+
+// outputLevel returns the minimum level of log points of e from the function
+// with the fully qualified name funcName that are written to Output. A level
+// stored in the context of the entry takes precedence over the configured
+// levels, see ContextWithLevel.
+func (c *Config) outputLevel(e *Entry, funcName string) LogLevel {
+	if e.hasLevel {
+		return e.level
+	}
+	if len(c.packageLevels) == 0 {
+		return c.LogLevel
+	}
+
+	pkg := packagePath(funcName)
+	for _, pl := range c.packageLevels {
+		if strings.HasPrefix(pkg, pl.path) && (len(pkg) == len(pl.path) || pkg[len(pl.path)] == '/') {
+			return pl.level
+		}
+	}
+	return c.LogLevel
+}
+
+// writesOutput reports whether a log point of e is written to Output, because
+// of its level or because it matches the filter.
+func (c *Config) writesOutput(e *Entry, log logPoint) bool {
+	if log.level >= c.outputLevel(e, log.funcName) {
+		return true
+	}
+	return c.filter != nil && c.filter.match(log)
+}
+
+// writesSinks reports whether a log point at level is written to any sink.
+func (c *Config) writesSinks(level LogLevel) bool {
+	for _, sink := range c.sinks {
+		if level >= sink.LogLevel {
+			return true
+		}
+	}
+	return false
 }
 
 func setLevelPadding(conf *Config) {
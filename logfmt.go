@@ -0,0 +1,117 @@
+package log
+
+import (
+	"strconv"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+type logfmtLogger struct{}
+
+func newLogfmtLogger() *logfmtLogger {
+	return &logfmtLogger{}
+}
+
+func (l *logfmtLogger) appendLogPoint(b []byte, c *Config, log logPoint) []byte {
+	if !c.DisableTime {
+		var scratch [64]byte
+		b = appendLogfmtPair(b, "time", string(c.appendTime(scratch[:0], log.time, time.RFC3339)))
+		b = append(b, ' ')
+	}
+	b = appendLogfmtPair(b, "level", c.prefix(log.level))
+	b = append(b, ' ')
+	if c.Caller != CallerOff {
+		var scratch [128]byte
+		b = appendLogfmtPair(b, "caller", string(appendCaller(scratch[:0], log)))
+		b = append(b, ' ')
+	}
+	b = appendLogfmtPair(b, "msg", log.msg)
+
+	for _, f := range log.fields {
+		// the built-in keys take precedence, the same as in the JSON logger
+		if l.builtinKey(c, log, f.Key) {
+			continue
+		}
+		b = append(b, ' ')
+		if s, ok := f.stringValue(); ok {
+			b = appendLogfmtPair(b, f.Key, s)
+			continue
+		}
+		var scratch [64]byte
+		b = appendLogfmtPair(b, f.Key, string(logfmtValue(scratch[:0], f)))
+	}
+
+	if len(log.stack) > 0 {
+		stack := appendStack(nil, log.stack, "")
+		b = append(b, ' ')
+		b = appendLogfmtPair(b, "stacktrace", string(stack[:len(stack)-1]))
+	}
+	return append(b, '\n')
+}
+
+// builtinKey reports whether key is written by the logger itself for log.
+func (l *logfmtLogger) builtinKey(c *Config, log logPoint, key string) bool {
+	switch key {
+	case "level", "msg":
+		return true
+	case "time":
+		return !c.DisableTime
+	case "caller":
+		return c.Caller != CallerOff
+	case "stacktrace":
+		return len(log.stack) > 0
+	}
+	return false
+}
+
+func logfmtValue(b []byte, f Field) []byte {
+	if f.typ == anyType {
+		switch v := f.iface.(type) {
+		case nil:
+			return append(b, "nil"...)
+		case error:
+			return append(b, v.Error()...)
+		}
+	} else if f.typ == errorType {
+		return append(b, f.iface.(error).Error()...)
+	}
+	return f.appendText(b)
+}
+
+func appendLogfmtPair(b []byte, key, value string) []byte {
+	b = appendLogfmtKey(b, key)
+	b = append(b, '=')
+	if logfmtNeedsQuoting(value) {
+		return strconv.AppendQuote(b, value)
+	}
+	return append(b, value...)
+}
+
+// appendLogfmtKey appends the key with any characters that aren't allowed in
+// a logfmt key replaced by underscores.
+func appendLogfmtKey(b []byte, key string) []byte {
+	if key == "" {
+		return append(b, '_')
+	}
+	for _, r := range key {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || !unicode.IsPrint(r) {
+			b = append(b, '_')
+			continue
+		}
+		b = utf8.AppendRune(b, r)
+	}
+	return b
+}
+
+func logfmtNeedsQuoting(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == utf8.RuneError || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
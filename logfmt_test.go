@@ -0,0 +1,125 @@
+package log_test
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/Strum355/log"
+)
+
+var logfmtRegex = regexp.MustCompile(`^time=\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:Z|(?:\+|-)\d{2}:\d{2}) level=(\w+) caller=([^:]+):\d+:(\S+\(\)) msg=`)
+
+func Test_LogfmtLogger(t *testing.T) {
+	defer b.Reset()
+
+	log.InitLogfmtLogger(&log.Config{
+		Output: b,
+	})
+
+	t.Run("Header", func(t *testing.T) {
+		defer b.Reset()
+		log.Warn("hello")
+
+		out := b.String()
+		if strings.Count(out, "\n") != 1 || !strings.HasSuffix(out, "\n") {
+			t.Fatalf("expected a single line log point: '%s'", out)
+		}
+
+		matched := logfmtRegex.FindStringSubmatch(out)
+		if len(matched) < 4 {
+			t.Fatalf("message '%s' didnt match regex", out)
+		}
+
+		if matched[1] != "WARN" {
+			t.Errorf("expected level: 'WARN'. actual level: '%s'", matched[1])
+		}
+
+		if matched[2] != "logfmt_test.go" {
+			t.Errorf("expected file: 'logfmt_test.go'. actual file: '%s'", matched[2])
+		}
+
+		if matched[3] != "func1()" {
+			t.Errorf("expected function: 'func1()'. actual function: '%s'", matched[3])
+		}
+
+		if !strings.HasSuffix(out, " msg=hello\n") {
+			t.Errorf("expected unquoted message: '%s'", out)
+		}
+	})
+
+	t.Run("Quoting", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			msg      string
+			fields   log.Fields
+			expected string
+		}{
+			{
+				name:     "Spaces",
+				msg:      "user logged in",
+				fields:   log.Fields{"name": "John Smith"},
+				expected: ` msg="user logged in" name="John Smith"`,
+			},
+			{
+				name:     "Quotes",
+				msg:      `said "hi"`,
+				fields:   log.Fields{"quote": `"`},
+				expected: ` msg="said \"hi\"" quote="\""`,
+			},
+			{
+				name:     "Newlines",
+				msg:      "multi\nline",
+				fields:   log.Fields{"text": "a\nb\tc"},
+				expected: ` msg="multi\nline" text="a\nb\tc"`,
+			},
+			{
+				name:     "Empty",
+				msg:      "",
+				fields:   log.Fields{"empty": ""},
+				expected: ` msg="" empty=""`,
+			},
+			{
+				name:     "Equals",
+				msg:      "a=b",
+				fields:   log.Fields{"query": "x=1"},
+				expected: ` msg="a=b" query="x=1"`,
+			},
+			{
+				name:     "Types",
+				msg:      "types",
+				fields:   log.Fields{"a": 1, "b": true, "c": nil, "d": errors.New("went wrong")},
+				expected: ` msg=types a=1 b=true c=nil d="went wrong"`,
+			},
+			{
+				name:     "Keys",
+				msg:      "keys",
+				fields:   log.Fields{"okay but": "epic", "x=y": 1},
+				expected: ` msg=keys okay_but=epic x_y=1`,
+			},
+			{
+				name:     "BuiltinKeys",
+				msg:      "builtin",
+				fields:   log.Fields{"time": "x", "level": "x", "caller": "x", "msg": "x", "other": 1},
+				expected: ` msg=builtin other=1`,
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				defer b.Reset()
+				log.WithFields(test.fields).Info(test.msg)
+
+				out := b.String()
+				if strings.Count(out, "\n") != 1 {
+					t.Fatalf("expected a single line log point: '%s'", out)
+				}
+
+				if !strings.HasSuffix(out, test.expected+"\n") {
+					t.Errorf("expected suffix: '%s'. actual: '%s'", test.expected, out)
+				}
+			})
+		}
+	})
+}
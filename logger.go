@@ -0,0 +1,249 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// Format is the format log points are written in.
+type Format uint8
+
+const (
+	// FormatSimple writes human readable log points, see InitSimpleLogger.
+	FormatSimple Format = iota
+	// FormatJSON writes JSON log points, see InitJSONLogger.
+	FormatJSON
+	// FormatLogfmt writes logfmt log points, see InitLogfmtLogger.
+	FormatLogfmt
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatSimple:
+		return "simple"
+	case FormatJSON:
+		return "json"
+	case FormatLogfmt:
+		return "logfmt"
+	}
+	return fmt.Sprintf("Format(%d)", f)
+}
+
+// Set implements flag.Value, see UnmarshalText.
+func (f *Format) Set(s string) error {
+	return f.UnmarshalText([]byte(s))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (f Format) MarshalText() ([]byte, error) {
+	if f > FormatLogfmt {
+		return nil, fmt.Errorf("invalid format %d", f)
+	}
+	return []byte(f.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. It accepts the names
+// simple, json and logfmt, case-insensitively.
+func (f *Format) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "simple":
+		*f = FormatSimple
+	case "json":
+		*f = FormatJSON
+	case "logfmt":
+		*f = FormatLogfmt
+	default:
+		return fmt.Errorf("invalid format %q, expected one of simple, json or logfmt", text)
+	}
+	return nil
+}
+
+// Logger writes log points with its own configuration, independently of the
+// global logger. A Logger is safe for concurrent use.
+type Logger struct {
+	config atomic.Pointer[Config]
+	root   *Entry
+}
+
+// options holds the settings a Logger is created with.
+type options struct {
+	config Config
+}
+
+// Option configures a Logger created with New.
+type Option func(*options) error
+
+// New returns a Logger configured by opts. Unlike the Init functions, invalid
+// settings are reported as an error instead of panicking or being silently
+// changed. Without options, it writes simple log points at LogDebug and above
+// to stdout.
+func New(opts ...Option) (*Logger, error) {
+	var o options
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, err
+		}
+	}
+
+	c, err := newLoggerConfig(&o.config)
+	if err != nil {
+		return nil, err
+	}
+
+	l := new(Logger)
+	l.config.Store(c)
+	l.root = &Entry{logger: l}
+	return l, nil
+}
+
+// newLoggerConfig returns a copy of conf with defaults set, reporting the
+// settings the Init functions silently change as errors.
+func newLoggerConfig(conf *Config) (*Config, error) {
+	if conf.UseStdErr && conf.Output != nil && conf.Output != os.Stdout {
+		return nil, errors.New("routing errors to stderr requires stdout as the output")
+	}
+	return newConfig(conf, conf.Format)
+}
+
+// WithConfig uses the settings in conf, which is copied. Options following it
+// override its settings.
+func WithConfig(conf *Config) Option {
+	return func(o *options) error {
+		if conf == nil {
+			return errors.New("config must not be nil")
+		}
+		o.config = *conf
+		return nil
+	}
+}
+
+// WithLevel sets the minimum level of log points that are written.
+func WithLevel(level LogLevel) Option {
+	return func(o *options) error {
+		if level > LogError {
+			return fmt.Errorf("invalid log level %d", level)
+		}
+		o.config.LogLevel = level
+		return nil
+	}
+}
+
+// WithOutput sets the writer log points are written to.
+func WithOutput(w io.Writer) Option {
+	return func(o *options) error {
+		if w == nil {
+			return errors.New("output must not be nil")
+		}
+		o.config.Output = w
+		return nil
+	}
+}
+
+// WithFormat sets the format log points are written in.
+func WithFormat(format Format) Option {
+	return func(o *options) error {
+		if format > FormatLogfmt {
+			return fmt.Errorf("invalid format %d", format)
+		}
+		o.config.Format = format
+		return nil
+	}
+}
+
+// WithPrefixes sets the names of the levels in log points.
+func WithPrefixes(errorPrefix, warnPrefix, infoPrefix, debugPrefix string) Option {
+	return func(o *options) error {
+		if errorPrefix == "" || warnPrefix == "" || infoPrefix == "" || debugPrefix == "" {
+			return errors.New("level prefixes must not be empty")
+		}
+		o.config.ErrorPrefix = errorPrefix
+		o.config.WarnPrefix = warnPrefix
+		o.config.InfoPrefix = infoPrefix
+		o.config.DebugPrefix = debugPrefix
+		return nil
+	}
+}
+
+// WithStdErr writes error log points to stderr instead of stdout. The output
+// must be stdout.
+func WithStdErr() Option {
+	return func(o *options) error {
+		o.config.UseStdErr = true
+		return nil
+	}
+}
+
+// SetDefault configures the global logger used by the package level functions
+// with the current configuration of l. Later reloads of l, see
+// Logger.WatchConfig, don't affect the global logger.
+func SetDefault(l *Logger) {
+	for {
+		current := l.config.Load()
+		// entered, so that its files aren't released while they are referenced
+		if !current.enter() {
+			continue
+		}
+		// copied, so that retiring the configuration of l once it is reloaded
+		// doesn't affect the global logger, which keeps its files open
+		c := *current
+		c.writes = current.copyWrites()
+		current.exit()
+
+		storeConfig(&c)
+		return
+	}
+}
+
+// SetLevel atomically changes the minimum level of log points written by l.
+func (l *Logger) SetLevel(level LogLevel) error {
+	_, _, err := updateLevel(&l.config, func(LogLevel) LogLevel { return level })
+	return err
+}
+
+// SetFilter atomically changes the filter of l, see Config.Filter. An empty
+// expression removes the filter.
+func (l *Logger) SetFilter(expr string) error {
+	return updateFilter(&l.config, expr)
+}
+
+// With adds typed fields to a new entry of the logger.
+func (l *Logger) With(fields ...Field) *Entry {
+	return l.root.With(fields...)
+}
+
+// WithFields adds fields to a new entry of the logger.
+func (l *Logger) WithFields(fields Fields) *Entry {
+	return l.root.WithFields(fields)
+}
+
+// WithError adds err to a new entry of the logger, see Entry.WithError.
+func (l *Logger) WithError(err error) *Entry {
+	return l.root.WithError(err)
+}
+
+// WithContext adds the Fields stored in ctx under Key to a new entry of the
+// logger.
+func (l *Logger) WithContext(ctx context.Context) *Entry {
+	return l.root.WithContext(ctx)
+}
+
+func (l *Logger) Debug(msg string) {
+	l.root.Debug(msg)
+}
+
+func (l *Logger) Info(msg string) {
+	l.root.Info(msg)
+}
+
+func (l *Logger) Warn(msg string) {
+	l.root.Warn(msg)
+}
+
+func (l *Logger) Error(msg string) {
+	l.root.Error(msg)
+}
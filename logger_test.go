@@ -0,0 +1,150 @@
+package log_test
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Strum355/log"
+)
+
+func Test_New(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := log.New(
+		log.WithOutput(&out),
+		log.WithLevel(log.LogInformational),
+		log.WithFormat(log.FormatLogfmt),
+		log.WithPrefixes("E", "W", "I", "D"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer b.Reset()
+	log.InitSimpleLogger(&log.Config{
+		Output: b,
+	})
+
+	logger.Debug("dropped")
+	logger.With(log.Int("n", 1)).Info("hello")
+	log.Info("global")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 log point, got '%s'", out.String())
+	}
+
+	matched := logfmtRegex.FindStringSubmatch(lines[0])
+	if len(matched) < 4 {
+		t.Fatalf("message '%s' didnt match regex", lines[0])
+	}
+
+	if matched[1] != "I" {
+		t.Errorf("expected level: 'I'. actual level: '%s'", matched[1])
+	}
+
+	if matched[2] != "logger_test.go" {
+		t.Errorf("expected file: 'logger_test.go'. actual file: '%s'", matched[2])
+	}
+
+	if !strings.HasSuffix(lines[0], " msg=hello n=1") {
+		t.Errorf("expected message and fields: '%s'", lines[0])
+	}
+
+	if !strings.Contains(b.String(), "global") || strings.Contains(b.String(), "hello") {
+		t.Errorf("expected global logger to be independent: '%s'", b.String())
+	}
+}
+
+func Test_NewErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []log.Option
+		expected string
+	}{
+		{
+			name:     "Level",
+			opts:     []log.Option{log.WithLevel(log.LogError + 1)},
+			expected: "invalid log level 4",
+		},
+		{
+			name:     "Output",
+			opts:     []log.Option{log.WithOutput(nil)},
+			expected: "output must not be nil",
+		},
+		{
+			name:     "Format",
+			opts:     []log.Option{log.WithFormat(log.FormatLogfmt + 1)},
+			expected: "invalid format 3",
+		},
+		{
+			name:     "Prefixes",
+			opts:     []log.Option{log.WithPrefixes("E", "", "I", "D")},
+			expected: "level prefixes must not be empty",
+		},
+		{
+			name:     "StdErr",
+			opts:     []log.Option{log.WithStdErr(), log.WithOutput(new(bytes.Buffer))},
+			expected: "routing errors to stderr requires stdout as the output",
+		},
+		{
+			name:     "Config",
+			opts:     []log.Option{log.WithConfig(&log.Config{RedactKeys: []string{"["}})},
+			expected: `invalid redact key pattern "["`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			logger, err := log.New(test.opts...)
+			if err == nil || err.Error() != test.expected {
+				t.Errorf("expected error: '%s'. actual error: '%v'", test.expected, err)
+			}
+			if logger != nil {
+				t.Error("expected no logger on error")
+			}
+		})
+	}
+
+	if _, err := log.New(log.WithStdErr(), log.WithOutput(os.Stdout)); err != nil {
+		t.Errorf("expected stderr routing with stdout output to be valid: %v", err)
+	}
+}
+
+func Test_SetDefault(t *testing.T) {
+	defer b.Reset()
+	logger, err := log.New(log.WithOutput(b), log.WithFormat(log.FormatJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log.SetDefault(logger)
+	log.Info("hello")
+
+	if !strings.HasPrefix(b.String(), "{") || !strings.Contains(b.String(), `"message":"hello"`) {
+		t.Errorf("expected JSON log point from the global logger: '%s'", b.String())
+	}
+}
+
+func Test_LoggerSetLevel(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := log.New(log.WithOutput(&out), log.WithLevel(log.LogError))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger.Info("dropped")
+	if err := logger.SetLevel(log.LogInformational); err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("written")
+
+	if strings.Contains(out.String(), "dropped") || !strings.Contains(out.String(), "written") {
+		t.Errorf("expected the new level to apply: '%s'", out.String())
+	}
+
+	if err := logger.SetLevel(log.LogError + 1); err == nil || err.Error() != "invalid log level 4" {
+		t.Errorf("expected invalid log level error: %v", err)
+	}
+}
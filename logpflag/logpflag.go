@@ -0,0 +1,25 @@
+// Package logpflag registers the logging flags of log.RegisterFlags on a
+// pflag.FlagSet, as used by cobra commands.
+package logpflag
+
+import (
+	"flag"
+
+	"github.com/Strum355/log"
+	"github.com/spf13/pflag"
+)
+
+// RegisterFlags defines the --log.level, --log.format, --log.output and
+// --log.caller flags on fs, or pflag.CommandLine if fs is nil, and returns the
+// log.Config they set once fs is parsed, see log.RegisterFlags. For a cobra
+// command, pass cmd.PersistentFlags().
+func RegisterFlags(fs *pflag.FlagSet) *log.Config {
+	if fs == nil {
+		fs = pflag.CommandLine
+	}
+
+	goFlags := flag.NewFlagSet("log", flag.ContinueOnError)
+	conf := log.RegisterFlags(goFlags)
+	fs.AddGoFlagSet(goFlags)
+	return conf
+}
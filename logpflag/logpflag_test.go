@@ -0,0 +1,22 @@
+package logpflag_test
+
+import (
+	"testing"
+
+	"github.com/Strum355/log"
+	"github.com/Strum355/log/logpflag"
+	"github.com/spf13/pflag"
+)
+
+func Test_RegisterFlags(t *testing.T) {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	conf := logpflag.RegisterFlags(fs)
+
+	if err := fs.Parse([]string{"--log.level=error", "--log.format", "logfmt"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if conf.LogLevel != log.LogError || conf.Format != log.FormatLogfmt {
+		t.Errorf("unexpected config: %+v", conf)
+	}
+}
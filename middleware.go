@@ -0,0 +1,80 @@
+package log
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DebugHeader is the header holding a token created by SignDebugToken, that
+// DebugMiddleware uses to set the level of a single request.
+const DebugHeader = "X-Debug-Log"
+
+// SignDebugToken returns a token for DebugHeader that sets the level of a
+// request to level until expires. The token is signed with secret, so that
+// clients can't enable verbose logging without it.
+func SignDebugToken(secret []byte, level LogLevel, expires time.Time) string {
+	payload := level.String() + "." + strconv.FormatInt(expires.Unix(), 10)
+	return payload + "." + debugTokenSignature(secret, payload)
+}
+
+func debugTokenSignature(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifyDebugToken returns the level of a token created by SignDebugToken,
+// if its signature is valid and it hasn't expired.
+func verifyDebugToken(secret []byte, token string, now time.Time) (LogLevel, bool) {
+	i := strings.LastIndexByte(token, '.')
+	if i < 0 {
+		return 0, false
+	}
+	payload, signature := token[:i], token[i+1:]
+	if !hmac.Equal([]byte(signature), []byte(debugTokenSignature(secret, payload))) {
+		return 0, false
+	}
+
+	levelText, expiresText, ok := strings.Cut(payload, ".")
+	if !ok {
+		return 0, false
+	}
+	expires, err := strconv.ParseInt(expiresText, 10, 64)
+	if err != nil || now.Unix() > expires {
+		return 0, false
+	}
+
+	var level LogLevel
+	if err := level.UnmarshalText([]byte(levelText)); err != nil {
+		return 0, false
+	}
+	return level, true
+}
+
+// DebugMiddleware returns HTTP middleware that stores the level of a valid
+// token in DebugHeader in the context of the request, see ContextWithLevel.
+// Log points of entries created with WithContext(r.Context()) are then
+// written at that level, regardless of the configured level. Requests with
+// missing, invalid or expired tokens are handled as usual. secret must not be
+// empty.
+func DebugMiddleware(secret []byte) func(http.Handler) http.Handler {
+	if len(secret) == 0 {
+		panic("debug token secret must not be empty")
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token := r.Header.Get(DebugHeader); token != "" {
+				if level, ok := verifyDebugToken(secret, token, time.Now()); ok {
+					r = r.WithContext(ContextWithLevel(r.Context(), level))
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
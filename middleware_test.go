@@ -0,0 +1,88 @@
+package log_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Strum355/log"
+)
+
+func Test_ContextWithLevel(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := log.New(log.WithOutput(&out), log.WithLevel(log.LogInformational))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	debugCtx := log.ContextWithLevel(context.Background(), log.LogDebug)
+	errorCtx := log.ContextWithLevel(context.WithValue(context.Background(), log.Key, log.Fields{"a": 1}), log.LogError)
+
+	logger.Debug("first debug")
+	logger.WithContext(debugCtx).Debug("second debug")
+	logger.WithContext(errorCtx).Warn("warn")
+	logger.WithContext(errorCtx).Error("error")
+
+	output := out.String()
+	if strings.Contains(output, "first debug") || strings.Contains(output, "warn") {
+		t.Errorf("expected the configured level to apply without a context level: '%s'", output)
+	}
+	if !strings.Contains(output, "second debug") || !strings.Contains(output, "error\n\ta='1'") {
+		t.Errorf("expected the context level to apply: '%s'", output)
+	}
+
+	if level, ok := log.LevelFromContext(debugCtx); !ok || level != log.LogDebug {
+		t.Errorf("expected debug level in context: %v %v", level, ok)
+	}
+	if _, ok := log.LevelFromContext(context.Background()); ok {
+		t.Error("expected no level in context")
+	}
+}
+
+func Test_DebugMiddleware(t *testing.T) {
+	secret := []byte("secret")
+
+	var out bytes.Buffer
+	logger, err := log.New(log.WithOutput(&out), log.WithLevel(log.LogInformational))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := log.DebugMiddleware(secret)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.WithContext(r.Context()).Debug("handled " + r.URL.Path)
+	}))
+
+	valid := log.SignDebugToken(secret, log.LogDebug, time.Now().Add(time.Minute))
+
+	tests := []struct {
+		name     string
+		token    string
+		expected bool
+	}{
+		{"Valid", valid, true},
+		{"Missing", "", false},
+		{"Expired", log.SignDebugToken(secret, log.LogDebug, time.Now().Add(-time.Minute)), false},
+		{"OtherSecret", log.SignDebugToken([]byte("other"), log.LogDebug, time.Now().Add(time.Minute)), false},
+		{"Tampered", "debug.9999999999" + valid[strings.LastIndex(valid, "."):], false},
+		{"Malformed", "1", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out.Reset()
+			r := httptest.NewRequest("GET", "/"+test.name, nil)
+			if test.token != "" {
+				r.Header.Set(log.DebugHeader, test.token)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			if written := strings.Contains(out.String(), "handled /"+test.name); written != test.expected {
+				t.Errorf("expected debug log point to be written: %v. output: '%s'", test.expected, out.String())
+			}
+		})
+	}
+}
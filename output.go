@@ -0,0 +1,153 @@
+package log
+
+import (
+	"io"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// writerLocks maps every io.Writer used as an output to the mutex that
+	// guards writes to it, so that writers that aren't safe for concurrent
+	// use are protected even when shared between configurations.
+	writerLocks   = make(map[io.Writer]*writerLockEntry)
+	writerLocksMu sync.Mutex
+
+	// sharedWriterLock guards writers that can't be used as map keys.
+	sharedWriterLock sync.Mutex
+)
+
+type writerLockEntry struct {
+	mu sync.Mutex
+	// refs is the number of reachable writerLock handles for the writer
+	refs int
+}
+
+// writerLock is a handle to the mutex guarding writes to a writer. Once no
+// handle for a writer is reachable, the writer is removed from writerLocks, so
+// writers that are no longer used by any configuration aren't kept alive.
+type writerLock struct {
+	*sync.Mutex
+	w io.Writer
+}
+
+// acquireWriterLock returns a handle to the mutex guarding writes to w.
+func acquireWriterLock(w io.Writer) *writerLock {
+	entry := lookupWriterLock(w)
+	if entry == nil {
+		return &writerLock{Mutex: &sharedWriterLock}
+	}
+
+	lock := &writerLock{Mutex: &entry.mu, w: w}
+	runtime.SetFinalizer(lock, releaseWriterLock)
+	return lock
+}
+
+// lookupWriterLock returns the entry for w with its references incremented,
+// or nil if w can't be used as a map key. Comparable types can still hold
+// values that can't be hashed, e.g. a struct with a func in an interface
+// field, which is only detected by the panic of the map access.
+func lookupWriterLock(w io.Writer) (entry *writerLockEntry) {
+	if w == nil {
+		return nil
+	}
+
+	writerLocksMu.Lock()
+	defer writerLocksMu.Unlock()
+	defer func() {
+		if recover() != nil {
+			entry = nil
+		}
+	}()
+
+	entry, ok := writerLocks[w]
+	if !ok {
+		entry = new(writerLockEntry)
+		writerLocks[w] = entry
+	}
+	entry.refs++
+	return entry
+}
+
+func releaseWriterLock(lock *writerLock) {
+	writerLocksMu.Lock()
+	defer writerLocksMu.Unlock()
+
+	if entry := writerLocks[lock.w]; entry != nil {
+		if entry.refs--; entry.refs == 0 {
+			delete(writerLocks, lock.w)
+		}
+	}
+}
+
+// write writes a formatted log point to the output for level with a single
+// call to Write, holding the output's lock so log points never interleave.
+func (c *Config) write(level LogLevel, b []byte) {
+	w, mu := c.Output, c.outputLock
+	if level == LogError && c.UseStdErr {
+		w, mu = os.Stderr, c.stderrLock
+	}
+
+	mu.Lock()
+	w.Write(b)
+	mu.Unlock()
+}
+
+// activeWrites counts the log points being written with a configuration, so
+// that its outputs are only closed once no log point is written to them.
+type activeWrites struct {
+	count   atomic.Int64
+	retired atomic.Bool
+	// files holds a reference to every file opened by a Watcher that is used
+	// as an output, released once the configuration is retired
+	files []*watchedFile
+}
+
+// copyWrites returns new activeWrites for a copy of c that is used
+// independently of c, taking new references to its files.
+func (c *Config) copyWrites() *activeWrites {
+	writes := &activeWrites{files: append([]*watchedFile(nil), c.writes.files...)}
+	for _, f := range writes.files {
+		f.acquire()
+	}
+	return writes
+}
+
+// enter marks a log point as being written with c. It returns false if c was
+// retired, in which case the log point must be written with the configuration
+// that replaced it.
+func (c *Config) enter() bool {
+	c.writes.count.Add(1)
+	if c.writes.retired.Load() {
+		c.writes.count.Add(-1)
+		return false
+	}
+	return true
+}
+
+// exit marks a log point entered with enter as written.
+func (c *Config) exit() {
+	c.writes.count.Add(-1)
+}
+
+// retire prevents c from being used for new log points and, once the log
+// points already being written with it are done, releases its files. c must
+// have been replaced by another configuration.
+func (c *Config) retire() {
+	writes := c.writes
+	if writes.retired.Swap(true) || len(writes.files) == 0 {
+		return
+	}
+
+	go func() {
+		for writes.count.Load() > 0 {
+			time.Sleep(time.Millisecond)
+		}
+		for _, f := range writes.files {
+			f.release()
+		}
+	}()
+}
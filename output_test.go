@@ -0,0 +1,173 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+type writerFunc func([]byte) (int, error)
+
+func (f writerFunc) Write(b []byte) (int, error) { return f(b) }
+
+// unhashableWriter is comparable, but hashing it panics as its writer is a
+// func.
+type unhashableWriter struct {
+	writerFunc
+}
+
+func Test_WriterLockUnhashable(t *testing.T) {
+	var b bytes.Buffer
+	c, err := newConfig(&Config{
+		Output: unhashableWriter{b.Write},
+	}, FormatSimple)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.outputLock.Mutex != &sharedWriterLock {
+		t.Error("expected the shared lock for an unhashable writer")
+	}
+
+	c.log(emptyEntry, LogInformational, "unhashable")
+	if !strings.Contains(b.String(), "unhashable") {
+		t.Errorf("expected log point to be written: '%s'", b.String())
+	}
+}
+
+func Test_WriterLockEviction(t *testing.T) {
+	w := new(bytes.Buffer)
+
+	func() {
+		c, err := newConfig(&Config{Output: w}, FormatJSON)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !hasWriterLock(w) {
+			t.Fatal("expected the writer to have a lock")
+		}
+		runtime.KeepAlive(c)
+	}()
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		runtime.GC()
+		if !hasWriterLock(w) {
+			return
+		}
+	}
+	t.Error("expected the lock of an unused writer to be removed")
+}
+
+func hasWriterLock(w *bytes.Buffer) bool {
+	writerLocksMu.Lock()
+	defer writerLocksMu.Unlock()
+	_, ok := writerLocks[w]
+	return ok
+}
+
+func Test_WatcherClosesAfterWrites(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "log.yaml")
+	first, second := filepath.Join(dir, "first.log"), filepath.Join(dir, "second.log")
+
+	logger, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte("output: "+first+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	w, err := logger.WatchConfig(path, 5*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	// a log point that loaded the configuration before it was replaced
+	c := logger.config.Load()
+	if !c.enter() {
+		t.Fatal("expected configuration to be active")
+	}
+
+	if err := os.WriteFile(path, []byte("output: "+second+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); logger.config.Load() == c; time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("expected configuration to be reloaded")
+		}
+	}
+	// give the watcher time to close the file if it didn't wait
+	time.Sleep(50 * time.Millisecond)
+
+	c.log(logger.root, LogInformational, "in flight")
+	c.exit()
+
+	out, err := os.ReadFile(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "in flight") {
+		t.Errorf("expected log point written while reloading to be written: '%s'", out)
+	}
+
+	if c.enter() {
+		t.Error("expected replaced configuration to be retired")
+	}
+
+	f := c.Output.(*os.File)
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(5 * time.Millisecond) {
+		if _, err := f.Stat(); errors.Is(err, os.ErrClosed) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the previous output to be closed once it is no longer used")
+		}
+	}
+}
+
+func Test_FileConfigClosesOutputs(t *testing.T) {
+	dir := t.TempDir()
+	fc := &fileConfig{
+		Output: filepath.Join(dir, "out.log"),
+		Sinks: []sinkConfig{
+			{Output: "stderr"},
+			{Output: filepath.Join(dir, "sink.log")},
+			{Output: filepath.Join(dir, "missing", "sink.log")},
+		},
+	}
+
+	var opened []io.Writer
+	open := func(path string) (io.Writer, error) {
+		w, err := openOutput(path)
+		if err == nil {
+			opened = append(opened, w)
+		}
+		return w, err
+	}
+	if _, err := fc.config(open, closeOutput); err == nil {
+		t.Fatal("expected error opening sink output")
+	}
+
+	if len(opened) != 3 {
+		t.Fatalf("expected 3 outputs to be opened, got %d", len(opened))
+	}
+	for _, w := range opened {
+		if w == os.Stderr {
+			continue
+		}
+		if _, err := w.Write([]byte("leaked\n")); !errors.Is(err, os.ErrClosed) {
+			t.Errorf("expected output to be closed: %v", err)
+		}
+	}
+	if _, err := os.Stderr.Stat(); err != nil {
+		t.Errorf("expected stderr to stay open: %v", err)
+	}
+}
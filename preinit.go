@@ -0,0 +1,139 @@
+package log
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// preInitBufferSize is the maximum number of log points buffered before the
+// logger is initialized.
+const preInitBufferSize = 256
+
+// preInitTimeout is how long log points are buffered before the logger is
+// assumed to never be initialized.
+var preInitTimeout = 5 * time.Second
+
+// preInit buffers log points created before any of the Init functions are
+// called, e.g. in init functions. They are replayed through the configured
+// logger once it is initialized. Warnings and errors are also written to
+// stderr by a default simple logger immediately, so they aren't lost if the
+// process exits before the logger is initialized. If the buffer fills up or
+// the logger isn't initialized within preInitTimeout, the remaining buffered
+// and any further log points are written to stderr too.
+var preInit struct {
+	sync.Mutex
+	points []preInitPoint
+	timer  *time.Timer
+}
+
+type preInitPoint struct {
+	entry *Entry
+	log   logPoint
+	// written is set if the log point was already written to stderr
+	written bool
+}
+
+// logPreInit buffers a log point if the logger isn't initialized yet. If it
+// was initialized concurrently, the new configuration is returned and the log
+// point isn't buffered.
+func (e *Entry) logPreInit(level LogLevel, msg string) *Config {
+	now := time.Now()
+	file, fileLine, funcName := e.resolveCaller()
+	fields := e.flatFields()
+
+	preInit.Lock()
+	defer preInit.Unlock()
+
+	// initialized while resolving the caller
+	if c := loadConfig(); c != nil {
+		return c
+	}
+
+	point := preInitPoint{
+		entry: e,
+		log: logPoint{
+			level:    level,
+			fileLine: fileLine,
+			file:     file,
+			funcName: funcName,
+			msg:      msg,
+			fields:   fields,
+			time:     now,
+		},
+		written: level >= LogWarning,
+	}
+	if point.written {
+		// emit processes and pools the fields, so it is passed a copy
+		state := logStatePool.Get().(*logState)
+		log := point.log
+		log.fields = append(state.fields[:0], log.fields...)
+		defaultConfig().emit(state, true, log)
+	}
+	preInit.points = append(preInit.points, point)
+
+	if len(preInit.points) >= preInitBufferSize {
+		flushPreInit(defaultConfig())
+	} else if preInit.timer == nil {
+		preInit.timer = time.AfterFunc(preInitTimeout, func() {
+			preInit.Lock()
+			defer preInit.Unlock()
+			if loadConfig() == nil {
+				flushPreInit(defaultConfig())
+			}
+		})
+	}
+	return nil
+}
+
+// storeConfig replaces and retires the global configuration and replays any
+// log points buffered before the logger was initialized.
+func storeConfig(c *Config) {
+	preInit.Lock()
+	previous := flushPreInit(c)
+	preInit.Unlock()
+
+	if previous != nil {
+		previous.retire()
+	}
+}
+
+// flushPreInit stores c as the global configuration and writes the buffered
+// log points with it, returning the previous configuration. preInit must be
+// locked.
+func flushPreInit(c *Config) *Config {
+	previous := globalConfig.Swap(c)
+
+	if preInit.timer != nil {
+		preInit.timer.Stop()
+		preInit.timer = nil
+	}
+
+	for _, point := range preInit.points {
+		log := point.log
+		output := c.writesOutput(point.entry, log) && !(point.written && c.writesStderr(log.level))
+		if !output && !c.writesSinks(log.level) {
+			continue
+		}
+		if c.EnableStackTrace && log.level >= c.StackTraceLevel {
+			log.stack = errorStack(log.fields)
+		}
+		c.emit(logStatePool.Get().(*logState), output, log)
+	}
+	preInit.points = nil
+	return previous
+}
+
+// writesStderr reports whether log points at level are written to stderr.
+func (c *Config) writesStderr(level LogLevel) bool {
+	return c.Output == os.Stderr || (level == LogError && c.UseStdErr)
+}
+
+// defaultConfig returns the configuration used if the logger is never
+// initialized.
+func defaultConfig() *Config {
+	c, _ := newConfig(&Config{
+		Output: os.Stderr,
+	}, FormatSimple)
+	return c
+}
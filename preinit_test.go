@@ -0,0 +1,156 @@
+package log
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// resetPreInit uninitializes the logger for the duration of a test.
+func resetPreInit(t *testing.T) {
+	previous := loadConfig()
+	globalConfig.Store(nil)
+	t.Cleanup(func() {
+		preInit.Lock()
+		defer preInit.Unlock()
+		if preInit.timer != nil {
+			preInit.timer.Stop()
+			preInit.timer = nil
+		}
+		preInit.points = nil
+		globalConfig.Store(previous)
+	})
+}
+
+// captureStderr redirects os.Stderr to a pipe for the duration of f.
+func captureStderr(t *testing.T, f func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() {
+		os.Stderr = stderr
+	}()
+
+	f()
+	w.Close()
+
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func Test_PreInitReplay(t *testing.T) {
+	resetPreInit(t)
+
+	WithFields(Fields{"password": "hunter2"}).Debug("debug before init")
+	Info("info before init")
+
+	var b strings.Builder
+	InitJSONLogger(&Config{
+		Output:     &b,
+		LogLevel:   LogInformational,
+		RedactKeys: []string{"password"},
+	})
+
+	Warn("after init")
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log points, got '%s'", b.String())
+	}
+
+	if !strings.Contains(lines[0], `"message":"info before init"`) || !strings.Contains(lines[0], `"_file":"preinit_test.go"`) {
+		t.Errorf("expected buffered log point to be replayed with its caller: '%s'", lines[0])
+	}
+
+	if !strings.Contains(lines[1], `"message":"after init"`) {
+		t.Errorf("expected log point after init: '%s'", lines[1])
+	}
+}
+
+func Test_PreInitOverflow(t *testing.T) {
+	resetPreInit(t)
+
+	out := captureStderr(t, func() {
+		for i := 0; i < preInitBufferSize; i++ {
+			Info("buffered")
+		}
+		Info("after overflow")
+	})
+
+	if count := strings.Count(out, "buffered\n"); count != preInitBufferSize {
+		t.Errorf("expected %d buffered log points on stderr, got %d", preInitBufferSize, count)
+	}
+
+	if !strings.HasSuffix(out, "[INFO ] preinit_test.go:88:func1() after overflow\n") {
+		t.Errorf("expected default simple logger to be used after overflow: '%s'", out)
+	}
+}
+
+func Test_PreInitTimeout(t *testing.T) {
+	resetPreInit(t)
+
+	timeout := preInitTimeout
+	preInitTimeout = 10 * time.Millisecond
+	defer func() {
+		preInitTimeout = timeout
+	}()
+
+	out := captureStderr(t, func() {
+		Info("never initialized")
+		time.Sleep(100 * time.Millisecond)
+		// wait for the timer to finish writing
+		preInit.Lock()
+		preInit.Unlock()
+	})
+
+	if !strings.Contains(out, "[INFO ] preinit_test.go:") || !strings.HasSuffix(out, "never initialized\n") {
+		t.Errorf("expected buffered log point on stderr: '%s'", out)
+	}
+}
+
+func Test_PreInitNoDuplicates(t *testing.T) {
+	resetPreInit(t)
+
+	out := captureStderr(t, func() {
+		Error("written once")
+		InitSimpleLogger(&Config{
+			Output: os.Stderr,
+		})
+	})
+
+	if count := strings.Count(out, "written once\n"); count != 1 {
+		t.Errorf("expected error to be written to stderr once, got %d: '%s'", count, out)
+	}
+}
+
+func Test_PreInitExit(t *testing.T) {
+	if os.Getenv("LOG_TEST_PREINIT_EXIT") == "1" {
+		// the process exits after returning, without the logger being
+		// initialized
+		Error("logged before exit")
+		return
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^Test_PreInitExit$")
+	cmd.Env = append(os.Environ(), "LOG_TEST_PREINIT_EXIT=1")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("%v: %s", err, stderr.String())
+	}
+
+	if out := stderr.String(); !strings.Contains(out, "[ERROR] preinit_test.go:") || !strings.HasSuffix(out, "logged before exit\n") {
+		t.Errorf("expected error to be written to stderr before exiting: '%s'", out)
+	}
+}
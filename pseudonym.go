@@ -0,0 +1,100 @@
+package log
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// pseudonymLength is the number of hex characters of the HMAC kept in a
+// pseudonym.
+const pseudonymLength = 16
+
+// Pseudonymizer replaces the values of identifying fields with a truncated
+// HMAC-SHA256 of the value. Pseudonyms are stable for a given secret, so log
+// points for the same user can still be joined, but can't be reversed
+// without the secret.
+type Pseudonymizer struct {
+	// Keys are case-insensitive glob patterns for the keys of the fields to
+	// pseudonymize, see path.Match.
+	Keys []string
+	// KeyID identifies Secret and is prefixed to every pseudonym, so that
+	// pseudonyms created with different secrets can be told apart after the
+	// secret is rotated.
+	KeyID  string
+	Secret []byte
+	keys   []string
+}
+
+// Pseudonym returns the pseudonym for value created with the given key ID and
+// secret. It can be used to look up log points of a known identifier, e.g.
+// during an investigation.
+func Pseudonym(keyID string, secret []byte, value string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(value))
+	sum := hex.EncodeToString(mac.Sum(nil))[:pseudonymLength]
+	if keyID == "" {
+		return sum
+	}
+	return keyID + ":" + sum
+}
+
+// Pseudonym returns the pseudonym for value using the configured key ID and
+// secret.
+func (p *Pseudonymizer) Pseudonym(value string) string {
+	return Pseudonym(p.KeyID, p.Secret, value)
+}
+
+// setPseudonymKeys validates and lowercases the configured key patterns.
+func setPseudonymKeys(conf *Config) error {
+	if conf.Pseudonymizer == nil {
+		return nil
+	}
+
+	// copied, as the keys are written to it
+	p := new(Pseudonymizer)
+	*p = *conf.Pseudonymizer
+	conf.Pseudonymizer = p
+
+	if len(p.Secret) == 0 {
+		return errors.New("pseudonymizer secret must not be empty")
+	}
+
+	p.keys = make([]string, 0, len(p.Keys))
+	for _, pattern := range p.Keys {
+		pattern = strings.ToLower(pattern)
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pseudonymizer key pattern %q", pattern)
+		}
+		p.keys = append(p.keys, pattern)
+	}
+	return nil
+}
+
+// pseudonymizeFields replaces the values of fields with the configured keys
+// with their pseudonyms.
+func (p *Pseudonymizer) pseudonymizeFields(fields []Field) {
+	if p == nil || len(p.keys) == 0 {
+		return
+	}
+
+	for i, f := range fields {
+		if !matchesAny(p.keys, f.Key) {
+			continue
+		}
+
+		s, ok := f.stringValue()
+		if !ok {
+			v := f.value()
+			if v == nil {
+				continue
+			}
+			s = fmt.Sprint(v)
+		}
+		fields[i] = String(f.Key, p.Pseudonym(s))
+	}
+}
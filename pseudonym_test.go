@@ -0,0 +1,55 @@
+package log_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Strum355/log"
+)
+
+func Test_Pseudonymizer(t *testing.T) {
+	defer b.Reset()
+
+	secret := []byte("secret")
+	log.InitSimpleLogger(&log.Config{
+		Output:     b,
+		RedactKeys: []string{"email"},
+		Pseudonymizer: &log.Pseudonymizer{
+			Keys:   []string{"user_*", "Email"},
+			KeyID:  "k1",
+			Secret: secret,
+		},
+	})
+
+	log.WithFields(log.Fields{
+		"user_id":   42,
+		"user_name": "alice",
+		"email":     "alice@example.com",
+		"region":    "eu",
+	}).Info("pseudonyms")
+
+	expected := log.Fields{
+		"user_id":   log.Pseudonym("k1", secret, "42"),
+		"user_name": log.Pseudonym("k1", secret, "alice"),
+		"email":     "[REDACTED]",
+		"region":    "eu",
+	}
+	for k, v := range expected {
+		if ok, fields := hasField(k, v, b.String(), t); !ok {
+			t.Errorf("expected fields to contain: '%s=%v'. actual fields total: %s", k, v, fields)
+		}
+	}
+
+	pseudonym := log.Pseudonym("k1", secret, "alice")
+	if !strings.HasPrefix(pseudonym, "k1:") || len(pseudonym) != len("k1:")+16 {
+		t.Errorf("expected key ID prefixed truncated pseudonym: '%s'", pseudonym)
+	}
+
+	if log.Pseudonym("k2", []byte("rotated"), "alice") == pseudonym {
+		t.Error("expected rotated secret to produce a different pseudonym")
+	}
+
+	if log.Pseudonym("", secret, "alice") != strings.TrimPrefix(pseudonym, "k1:") {
+		t.Error("expected pseudonym without key ID to not be prefixed")
+	}
+}
@@ -0,0 +1,7 @@
+//go:build race
+
+package log
+
+// raceEnabled is true if the race detector is enabled, which makes sync.Pool
+// drop items at random and so causes allocations.
+const raceEnabled = true
@@ -0,0 +1,206 @@
+package log
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// Common patterns that can be used for Config.RedactPatterns.
+var (
+	RedactBearerTokens = regexp.MustCompile(`(?i)\bbearer\s+[a-z0-9\-._~+/]+=*`)
+	RedactCardNumbers  = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+)
+
+// Secret is a string that is rendered masked by every logger, making it safe
+// to pass sensitive values in Fields, including in exported fields of structs.
+// The simple and logfmt loggers format structs with fmt, which can't call the
+// methods of unexported fields, so a Secret in an unexported field is written
+// unmasked by them.
+type Secret string
+
+func (Secret) String() string {
+	return redacted
+}
+
+func (Secret) GoString() string {
+	return redacted
+}
+
+func (Secret) MarshalText() ([]byte, error) {
+	return []byte(redacted), nil
+}
+
+func (Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + redacted + `"`), nil
+}
+
+// setRedactKeys validates and lowercases the configured key patterns.
+func setRedactKeys(conf *Config) error {
+	conf.redactKeys = make([]string, 0, len(conf.RedactKeys))
+	for _, pattern := range conf.RedactKeys {
+		pattern = strings.ToLower(pattern)
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid redact key pattern %q", pattern)
+		}
+		conf.redactKeys = append(conf.redactKeys, pattern)
+	}
+	return nil
+}
+
+// redactFields masks the values of fields with keys matching the configured
+// patterns and scrubs the configured value patterns from the text of values.
+// Errors are scrubbed of both, as errors created with Entry.Err render their
+// fields in their message.
+func (c *Config) redactFields(fields []Field) {
+	if len(c.redactKeys) == 0 && len(c.RedactPatterns) == 0 {
+		return
+	}
+
+	for i, f := range fields {
+		if c.isRedactedKey(f.Key) {
+			fields[i] = String(f.Key, redacted)
+			continue
+		}
+
+		if err, ok := f.error(); ok {
+			if scrubbed, ok := c.scrubError(err); ok {
+				fields[i] = Field{Key: f.Key, typ: errorType, iface: scrubbed}
+			}
+			continue
+		}
+
+		if len(c.RedactPatterns) == 0 {
+			continue
+		}
+
+		if s, ok := f.stringValue(); ok {
+			if scrubbed := c.scrub(s); scrubbed != s {
+				fields[i] = String(f.Key, scrubbed)
+			}
+			continue
+		}
+
+		if f.typ == anyType && f.iface != nil {
+			// values are written as their text, e.g. from a fmt.Stringer
+			s := string(f.appendText(nil))
+			if scrubbed := c.scrub(s); scrubbed != s {
+				fields[i] = String(f.Key, scrubbed)
+			}
+		}
+	}
+}
+
+// scrub masks every match of the configured value patterns in s.
+func (c *Config) scrub(s string) string {
+	for _, pattern := range c.RedactPatterns {
+		s = pattern.ReplaceAllLiteralString(s, redacted)
+	}
+	return s
+}
+
+// redactedError replaces a logged error whose messages matched any of the
+// configured value patterns, holding its scrubbed message and chain.
+type redactedError struct {
+	msg   string
+	chain []errorLayer
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+// scrubError returns a redactedError if the message of err or of any error in
+// its chain matches any of the configured value patterns, or renders fields
+// with keys matching the configured key patterns, see Entry.Err.
+func (c *Config) scrubError(err error) (error, bool) {
+	s := errorScrubber{c: c}
+	if len(c.redactKeys) > 0 {
+		// outermost first, as the messages of outer errors contain those of
+		// the errors they wrap
+		walkErrors(err, func(err error) {
+			if e, ok := err.(*entryError); ok {
+				if masked, ok := e.redactedMessage(c); ok {
+					s.replace = append(s.replace, e.Error(), masked)
+				}
+			}
+		})
+	}
+	if len(s.replace) == 0 && len(c.RedactPatterns) == 0 {
+		return nil, false
+	}
+
+	msg := err.Error()
+	scrubbed := s.scrub(msg)
+	changed := scrubbed != msg
+
+	var chain []errorLayer
+	if hasErrorChain(err) {
+		chain = errorChain(err)
+		if s.scrubChain(chain) {
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil, false
+	}
+	return &redactedError{msg: scrubbed, chain: chain}, true
+}
+
+// errorScrubber scrubs the messages of an error and its chain.
+type errorScrubber struct {
+	c *Config
+	// replace holds pairs of the messages of errors rendering redacted fields
+	// and their masked replacements
+	replace []string
+}
+
+// scrub masks the messages to replace and the configured value patterns in
+// msg.
+func (s *errorScrubber) scrub(msg string) string {
+	for i := 0; i < len(s.replace); i += 2 {
+		msg = strings.Replace(msg, s.replace[i], s.replace[i+1], -1)
+	}
+	return s.c.scrub(msg)
+}
+
+// scrubChain scrubs the messages of chain in place, reporting whether any of
+// them changed.
+func (s *errorScrubber) scrubChain(chain []errorLayer) bool {
+	changed := false
+	for i := range chain {
+		if scrubbed := s.scrub(chain[i].Message); scrubbed != chain[i].Message {
+			chain[i].Message = scrubbed
+			changed = true
+		}
+		for _, errs := range chain[i].Errors {
+			if s.scrubChain(errs) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+func (c *Config) isRedactedKey(key string) bool {
+	return matchesAny(c.redactKeys, key)
+}
+
+// matchesAny reports whether key matches any of the lowercased glob patterns,
+// ignoring case.
+func matchesAny(patterns []string, key string) bool {
+	if len(patterns) == 0 {
+		return false
+	}
+	key = strings.ToLower(key)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
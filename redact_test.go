@@ -0,0 +1,145 @@
+package log_test
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/Strum355/log"
+)
+
+func Test_Redaction(t *testing.T) {
+	defer b.Reset()
+
+	inits := []struct {
+		name string
+		init func(*log.Config)
+	}{
+		{"Simple", log.InitSimpleLogger},
+		{"JSON", log.InitJSONLogger},
+		{"Logfmt", log.InitLogfmtLogger},
+	}
+
+	for _, test := range inits {
+		t.Run(test.name, func(t *testing.T) {
+			defer b.Reset()
+			test.init(&log.Config{
+				Output:         b,
+				RedactKeys:     []string{"*password*", "Token"},
+				RedactPatterns: []*regexp.Regexp{log.RedactBearerTokens, log.RedactCardNumbers},
+			})
+
+			log.WithFields(log.Fields{
+				"api_key":       log.Secret("hunter2-secret"),
+				"DB_PASSWORD":   "hunter2-password",
+				"token":         "hunter2-token",
+				"authorization": "Bearer hunter2.bearer",
+				"payment":       "card 4111 1111 1111 1111 declined",
+				"user":          "alice",
+			}).Info("redaction")
+
+			out := b.String()
+			if strings.Contains(out, "hunter2") || strings.Contains(out, "4111") {
+				t.Errorf("expected secrets to be redacted: '%s'", out)
+			}
+
+			for _, expected := range []string{"alice", "card [REDACTED] declined"} {
+				if !strings.Contains(out, expected) {
+					t.Errorf("expected output to contain '%s': '%s'", expected, out)
+				}
+			}
+
+			if strings.Count(out, "[REDACTED]") != 5 {
+				t.Errorf("expected 5 redacted values: '%s'", out)
+			}
+		})
+	}
+
+	t.Run("SecretFormatting", func(t *testing.T) {
+		s := log.Secret("hunter2")
+		for _, out := range []string{fmt.Sprint(s), fmt.Sprintf("%v %s %#v", s, s, s), fmt.Sprintf("%+v", struct{ S log.Secret }{s})} {
+			if strings.Contains(out, "hunter2") {
+				t.Errorf("expected secret to be masked: '%s'", out)
+			}
+		}
+	})
+}
+
+type stringerValue string
+
+func (s stringerValue) String() string { return "value " + string(s) }
+
+func Test_RedactionRenderedValues(t *testing.T) {
+	defer b.Reset()
+
+	token := "Bearer abc.def.ghi"
+	inner := fmt.Errorf("request with %s failed", token)
+	wrapped := fmt.Errorf("calling api: %w", inner)
+
+	inits := map[string]func(*log.Config){
+		"Simple": log.InitSimpleLogger,
+		"JSON":   log.InitJSONLogger,
+		"Logfmt": log.InitLogfmtLogger,
+	}
+
+	for name, init := range inits {
+		t.Run(name, func(t *testing.T) {
+			defer b.Reset()
+			init(&log.Config{
+				Output:         b,
+				RedactPatterns: []*regexp.Regexp{log.RedactBearerTokens},
+			})
+
+			log.WithError(wrapped).
+				With(log.NamedErr("inner", inner), log.Any("stringer", stringerValue(token)), log.Any("list", []string{token})).
+				Error("failed")
+
+			out := b.String()
+			if strings.Contains(out, "abc.def.ghi") {
+				t.Errorf("expected token to be redacted: '%s'", out)
+			}
+			if !strings.Contains(out, "calling api: request with [REDACTED] failed") || !strings.Contains(out, "value [REDACTED]") {
+				t.Errorf("expected the rest of the values to be kept: '%s'", out)
+			}
+			if name == "JSON" && (!strings.Contains(out, `"error_chain":[{"message":"calling api: request with [REDACTED] failed","type":"*fmt.wrapError"}`) ||
+				!strings.Contains(out, `{"message":"request with [REDACTED] failed","type":"*errors.errorString"}`)) {
+				t.Errorf("expected the error chain to be redacted: '%s'", out)
+			}
+		})
+	}
+}
+
+func Test_RedactionEntryErr(t *testing.T) {
+	defer b.Reset()
+
+	cause := log.WithFields(log.Fields{"password": "hunter2", "user": "alice"}).Err("login failed")
+	wrapped := fmt.Errorf("handling request: %w", log.WithError(cause).Err("request failed"))
+
+	inits := map[string]func(*log.Config){
+		"Simple": log.InitSimpleLogger,
+		"JSON":   log.InitJSONLogger,
+		"Logfmt": log.InitLogfmtLogger,
+	}
+
+	for name, init := range inits {
+		t.Run(name, func(t *testing.T) {
+			defer b.Reset()
+			init(&log.Config{
+				Output:     b,
+				RedactKeys: []string{"password"},
+			})
+
+			log.WithError(cause).Error("failed")
+			log.With(log.NamedErr("wrapped", wrapped)).Error("failed")
+
+			out := b.String()
+			if strings.Contains(out, "hunter2") {
+				t.Errorf("expected password to be redacted from error messages: '%s'", out)
+			}
+			if !strings.Contains(out, "login failed password='[REDACTED]' user='alice'") {
+				t.Errorf("expected the rest of the error message to be kept: '%s'", out)
+			}
+		})
+	}
+}
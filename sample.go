@@ -0,0 +1,93 @@
+package log
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// Sampling limits the number of repetitive log points that are written, e.g.
+// from a hot loop. Log points are counted by their level and message every
+// Tick: the first Initial of them are written, and after that every
+// Thereafter-th, or none if Thereafter is 0.
+type Sampling struct {
+	Initial    int
+	Thereafter int
+	// Tick defaults to one second.
+	Tick time.Duration
+}
+
+// samplerBuckets is the number of counters per level. Messages sharing a
+// counter are sampled together.
+const samplerBuckets = 1024
+
+type sampler struct {
+	initial    uint64
+	thereafter uint64
+	tick       int64
+	counters   [LogError + 1][samplerBuckets]sampleCounter
+}
+
+type sampleCounter struct {
+	resetAt atomic.Int64
+	n       atomic.Uint64
+}
+
+// setSampler validates the sampling settings and creates the sampler. Every
+// configuration has its own counters, so they are reset when it is replaced.
+func setSampler(conf *Config) error {
+	conf.sampler = nil
+	s := conf.Sampling
+	if s == nil {
+		return nil
+	}
+	if s.Initial < 0 || s.Thereafter < 0 || s.Tick < 0 {
+		return errors.New("sampling settings must not be negative")
+	}
+
+	conf.sampler = &sampler{
+		initial:    uint64(s.Initial),
+		thereafter: uint64(s.Thereafter),
+		tick:       int64(s.Tick),
+	}
+	if conf.sampler.tick == 0 {
+		conf.sampler.tick = int64(time.Second)
+	}
+	return nil
+}
+
+// sample reports whether a log point with level and msg created at now is
+// written.
+func (s *sampler) sample(level LogLevel, msg string, now time.Time) bool {
+	if s == nil {
+		return true
+	}
+
+	// FNV-1a, inlined so that msg doesn't escape
+	h := uint32(2166136261)
+	for i := 0; i < len(msg); i++ {
+		h ^= uint32(msg[i])
+		h *= 16777619
+	}
+
+	n := s.counters[level][h%samplerBuckets].inc(now.UnixNano(), s.tick)
+	if n <= s.initial {
+		return true
+	}
+	return s.thereafter > 0 && (n-s.initial)%s.thereafter == 0
+}
+
+// inc counts a log point, starting a new tick if the current one is over.
+func (c *sampleCounter) inc(now, tick int64) uint64 {
+	resetAt := c.resetAt.Load()
+	if now < resetAt {
+		return c.n.Add(1)
+	}
+
+	// another log point may have started the new tick concurrently
+	if !c.resetAt.CompareAndSwap(resetAt, now+tick) {
+		return c.n.Add(1)
+	}
+	c.n.Store(1)
+	return 1
+}
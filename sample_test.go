@@ -0,0 +1,45 @@
+package log_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Strum355/log"
+)
+
+func Test_Sampling(t *testing.T) {
+	defer b.Reset()
+	log.InitSimpleLogger(&log.Config{
+		Output: b,
+		Sampling: &log.Sampling{
+			Initial:    2,
+			Thereafter: 3,
+			Tick:       time.Hour,
+		},
+	})
+
+	for i := 0; i < 10; i++ {
+		log.WithFields(log.Fields{"i": i}).Info("repeated")
+		log.Warn("repeated")
+	}
+	log.Info("other")
+
+	out := b.String()
+	// the 1st, 2nd, 5th and 8th log point of every level and message
+	for _, i := range []string{"0", "1", "4", "7"} {
+		if !strings.Contains(out, "i='"+i+"'") {
+			t.Errorf("expected log point %s to be sampled: '%s'", i, out)
+		}
+	}
+	if count := strings.Count(out, "[INFO ]"); count != 5 {
+		t.Errorf("expected 4 sampled and 1 other info log points, got %d: '%s'", count, out)
+	}
+	if count := strings.Count(out, "[WARN ]"); count != 4 {
+		t.Errorf("expected 4 sampled warn log points, got %d: '%s'", count, out)
+	}
+
+	if _, err := log.New(log.WithConfig(&log.Config{Sampling: &log.Sampling{Initial: -1}})); err == nil {
+		t.Error("expected negative sampling settings to be rejected")
+	}
+}
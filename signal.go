@@ -0,0 +1,93 @@
+//go:build unix
+
+package log
+
+import (
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+)
+
+// HandleSignals changes the level of the global logger on SIGUSR1 and
+// SIGUSR2, to make it more verbose during an incident without restarting.
+// Every SIGUSR1 lowers the level by one step, e.g. from info to debug, and
+// SIGUSR2 restores the level from before the first SIGUSR1. Every change is
+// logged. The returned function stops handling the signals.
+//
+// On systems without SIGUSR1 and SIGUSR2, e.g. Windows, HandleSignals does
+// nothing.
+func HandleSignals() (stop func()) {
+	return handleSignals(&globalConfig, emptyEntry)
+}
+
+// HandleSignals changes the level of l on SIGUSR1 and SIGUSR2, see the
+// HandleSignals function.
+func (l *Logger) HandleSignals() (stop func()) {
+	return handleSignals(&l.config, l.root)
+}
+
+func handleSignals(config *atomic.Pointer[Config], entry *Entry) func() {
+	entry = entry.withFixedCaller()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2)
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		// configured is the level before the first SIGUSR1, restored by SIGUSR2
+		var configured *LogLevel
+		for {
+			var sig os.Signal
+			select {
+			case <-done:
+				return
+			case sig = <-signals:
+			}
+
+			var (
+				from, to LogLevel
+				err      error
+			)
+			if sig == syscall.SIGUSR1 {
+				from, to, err = updateLevel(config, func(level LogLevel) LogLevel {
+					if level > LogDebug {
+						return level - 1
+					}
+					return level
+				})
+				if err == nil && configured == nil {
+					configured = &from
+				}
+			} else {
+				if configured == nil {
+					continue
+				}
+				from, to, err = updateLevel(config, func(LogLevel) LogLevel { return *configured })
+				configured = nil
+			}
+			if err != nil {
+				continue
+			}
+
+			// logged at no less than warn, so the change is usually visible
+			level := LogWarning
+			if to > level {
+				level = to
+			}
+			entry.With(
+				String("signal", sig.String()),
+				String("log_level", from.String()+" -> "+to.String()),
+			).log(level, "log level changed")
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+		<-stopped
+	}
+}
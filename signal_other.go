@@ -0,0 +1,13 @@
+//go:build !unix
+
+package log
+
+// HandleSignals does nothing on systems without SIGUSR1 and SIGUSR2.
+func HandleSignals() (stop func()) {
+	return func() {}
+}
+
+// HandleSignals does nothing on systems without SIGUSR1 and SIGUSR2.
+func (l *Logger) HandleSignals() (stop func()) {
+	return func() {}
+}
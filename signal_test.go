@@ -0,0 +1,103 @@
+//go:build unix
+
+package log_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/Strum355/log"
+)
+
+// syncBuffer is a bytes.Buffer that can be read while it is written to.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func Test_HandleSignals(t *testing.T) {
+	out := new(syncBuffer)
+	logger, err := log.New(log.WithOutput(out), log.WithLevel(log.LogWarning))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stop := logger.HandleSignals()
+	defer stop()
+
+	signal := func(sig syscall.Signal, expected string) {
+		t.Helper()
+		if err := syscall.Kill(syscall.Getpid(), sig); err != nil {
+			t.Fatal(err)
+		}
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+			if strings.Contains(out.String(), expected) {
+				return
+			}
+		}
+		t.Fatalf("expected '%s' in output: '%s'", expected, out.String())
+	}
+
+	logger.Info("first info")
+	signal(syscall.SIGUSR1, "log_level='warn -> info'")
+	logger.Info("second info")
+	logger.Debug("first debug")
+	signal(syscall.SIGUSR1, "log_level='info -> debug'")
+	logger.Debug("second debug")
+	signal(syscall.SIGUSR2, "log_level='debug -> warn'")
+	logger.Info("third info")
+
+	output := out.String()
+	for _, s := range []string{"first info", "first debug", "third info"} {
+		if strings.Contains(output, s) {
+			t.Errorf("unexpected '%s' in output: '%s'", s, output)
+		}
+	}
+	for _, s := range []string{"second info", "second debug", "signal_test.go:42:Test_HandleSignals() log level changed"} {
+		if !strings.Contains(output, s) {
+			t.Errorf("expected '%s' in output: '%s'", s, output)
+		}
+	}
+}
+
+func Test_HandleSignalsJSON(t *testing.T) {
+	out := new(syncBuffer)
+	logger, err := log.New(log.WithOutput(out), log.WithLevel(log.LogInformational), log.WithFormat(log.FormatJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stop := logger.HandleSignals()
+	defer stop()
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); out.String() == "" && time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(out.String()), &data); err != nil {
+		t.Fatalf("error unmarshalling log point '%s': %v", out.String(), err)
+	}
+	if data["level"] != "WARN" || data["log_level"] != "info -> debug" {
+		t.Errorf("expected level and level change in log point: %v", data)
+	}
+}
@@ -1,7 +1,7 @@
 package log
 
 import (
-	"fmt"
+	"strconv"
 )
 
 type simpleLogger struct{}
@@ -10,8 +10,65 @@ func newSimpleLogger() *simpleLogger {
 	return &simpleLogger{}
 }
 
-func (s *simpleLogger) createLogPoint(log logPoint) {
-	fmt.Fprintf(log.b, "%s [%-*s] %s:%d:%s() %s\n", log.time.Format("2006-01-02 15:04:05Z07:00"), config.levelPadding, getPrefix(log.level), log.file, log.fileLine, log.funcName, log.msg)
+func (s *simpleLogger) appendLogPoint(b []byte, c *Config, log logPoint) []byte {
+	if !c.DisableTime {
+		if c.color {
+			b = append(b, colorDim...)
+		}
+		b = c.appendTime(b, log.time, "2006-01-02 15:04:05Z07:00")
+		if c.color {
+			b = append(b, colorReset...)
+		}
+		b = append(b, ' ')
+	}
 
-	log.b.WriteString(log.fields.format())
+	b = append(b, '[')
+	if c.color {
+		b = append(b, levelColor(log.level)...)
+	}
+	prefix := c.prefix(log.level)
+	b = append(b, prefix...)
+	for i := len(prefix); i < c.levelPadding; i++ {
+		b = append(b, ' ')
+	}
+	if c.color {
+		b = append(b, colorReset...)
+	}
+	b = append(b, "] "...)
+
+	if c.Caller != CallerOff {
+		if c.color {
+			b = append(b, colorDim...)
+		}
+		b = appendCaller(b, log)
+		if c.color {
+			b = append(b, colorReset...)
+		}
+		b = append(b, ' ')
+	}
+
+	b = append(b, log.msg...)
+	b = append(b, '\n')
+
+	if len(log.fields) > 0 {
+		b = append(b, '\t')
+		b = appendSimpleFields(b, log.fields, c.color)
+		b = append(b, '\n')
+	}
+
+	if len(log.stack) > 0 {
+		b = append(b, "\tstacktrace:\n"...)
+		b = appendStack(b, log.stack, "\t\t")
+	}
+	return b
+}
+
+// appendCaller appends the caller of the log point as file:line:function().
+func appendCaller(b []byte, log logPoint) []byte {
+	b = append(b, log.file...)
+	b = append(b, ':')
+	b = strconv.AppendInt(b, int64(log.fileLine), 10)
+	b = append(b, ':')
+	b = append(b, log.funcName...)
+	return append(b, "()"...)
 }
@@ -0,0 +1,110 @@
+package log
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// stackFrame is a single frame of a stack trace attached to a log point.
+type stackFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// captureStack returns the stack of the calling goroutine starting at the
+// caller of the log point, as determined by getFunctionInfo.
+func captureStack(skip int) []stackFrame {
+	var stack []stackFrame
+	walkFrames(func(f frame) bool {
+		if len(stack) == 0 {
+			if isHelper(f.function) {
+				return true
+			}
+			if skip > 0 {
+				skip--
+				return true
+			}
+		}
+		stack = append(stack, stackFrame{f.function, f.file, f.line})
+		return true
+	})
+	return stack
+}
+
+// errorStack returns the stack trace carried by the innermost error in the
+// chain of any error in fields that exposes a non-empty one.
+func errorStack(fields []Field) []stackFrame {
+	for _, f := range fields {
+		err, ok := f.error()
+		if !ok {
+			continue
+		}
+
+		var pcs []uintptr
+		walkErrors(err, func(err error) {
+			if errPCs := stackPCs(err); len(errPCs) > 0 {
+				pcs = errPCs
+			}
+		})
+
+		if len(pcs) > 0 {
+			return resolveStack(pcs)
+		}
+	}
+	return nil
+}
+
+// stackPCs returns the program counters of the stack carried by err. Errors
+// can expose their stack with a Callers() []uintptr method, or with a
+// StackTrace method returning a slice of uintptr-based frames such as the one
+// provided by github.com/pkg/errors.
+func stackPCs(err error) []uintptr {
+	if e, ok := err.(interface{ Callers() []uintptr }); ok {
+		return e.Callers()
+	}
+
+	method := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !method.IsValid() {
+		return nil
+	}
+
+	typ := method.Type()
+	if typ.NumIn() != 0 || typ.NumOut() != 1 || typ.Out(0).Kind() != reflect.Slice || typ.Out(0).Elem().Kind() != reflect.Uintptr {
+		return nil
+	}
+
+	trace := method.Call(nil)[0]
+	pcs := make([]uintptr, trace.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(trace.Index(i).Uint())
+	}
+	return pcs
+}
+
+func resolveStack(pcs []uintptr) []stackFrame {
+	stack := make([]stackFrame, 0, len(pcs))
+	for _, pc := range pcs {
+		for _, f := range lookupFrames(pc) {
+			stack = append(stack, stackFrame{f.function, f.file, f.line})
+		}
+	}
+	return stack
+}
+
+// appendStack appends stack as an indented block in the style of a goroutine
+// trace, each line prefixed by indent.
+func appendStack(b []byte, stack []stackFrame, indent string) []byte {
+	for _, f := range stack {
+		b = append(b, indent...)
+		b = append(b, f.Function...)
+		b = append(b, "()\n"...)
+		b = append(b, indent...)
+		b = append(b, '\t')
+		b = append(b, f.File...)
+		b = append(b, ':')
+		b = strconv.AppendInt(b, int64(f.Line), 10)
+		b = append(b, '\n')
+	}
+	return b
+}
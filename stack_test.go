@@ -0,0 +1,163 @@
+package log_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/Strum355/log"
+)
+
+type callersError struct {
+	pcs []uintptr
+}
+
+func (e *callersError) Error() string      { return "callers error" }
+func (e *callersError) Callers() []uintptr { return e.pcs }
+
+func newCallersError() error {
+	pcs := make([]uintptr, 32)
+	return &callersError{pcs[:runtime.Callers(1, pcs)]}
+}
+
+// stackTraceFrame mirrors github.com/pkg/errors.Frame
+type stackTraceFrame uintptr
+
+type stackTraceError struct {
+	pcs []uintptr
+}
+
+func (e *stackTraceError) Error() string { return "stack trace error" }
+func (e *stackTraceError) StackTrace() []stackTraceFrame {
+	frames := make([]stackTraceFrame, len(e.pcs))
+	for i, pc := range e.pcs {
+		frames[i] = stackTraceFrame(pc)
+	}
+	return frames
+}
+
+func newStackTraceError() error {
+	pcs := make([]uintptr, 32)
+	return &stackTraceError{pcs[:runtime.Callers(1, pcs)]}
+}
+
+func Test_StackTrace(t *testing.T) {
+	defer b.Reset()
+
+	t.Run("Simple", func(t *testing.T) {
+		defer b.Reset()
+		log.InitSimpleLogger(&log.Config{
+			Output:           b,
+			EnableStackTrace: true,
+			StackTraceLevel:  log.LogError,
+		})
+
+		log.Warn("no stack")
+		if strings.Contains(b.String(), "stacktrace") {
+			t.Errorf("expected no stack trace below StackTraceLevel: '%s'", b.String())
+		}
+		b.Reset()
+
+		log.Error("stack")
+		lines := strings.Split(b.String(), "\n")
+		if len(lines) < 4 || lines[1] != "\tstacktrace:" {
+			t.Fatalf("expected stack trace block: '%s'", b.String())
+		}
+
+		if lines[2] != "\t\tgithub.com/Strum355/log_test.Test_StackTrace.func1()" {
+			t.Errorf("expected caller as first frame, got '%s'", lines[2])
+		}
+
+		if !strings.HasPrefix(lines[3], "\t\t\t") || !strings.Contains(lines[3], "stack_test.go:") {
+			t.Errorf("expected indented file and line, got '%s'", lines[3])
+		}
+	})
+
+	tests := []struct {
+		name     string
+		err      error
+		function string
+	}{
+		{
+			name:     "Captured",
+			function: "github.com/Strum355/log_test.Test_StackTrace.func2",
+		},
+		{
+			name:     "CallersError",
+			err:      fmt.Errorf("wrapped: %w", newCallersError()),
+			function: "github.com/Strum355/log_test.newCallersError",
+		},
+		{
+			name:     "StackTraceError",
+			err:      newStackTraceError(),
+			function: "github.com/Strum355/log_test.newStackTraceError",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("JSON"+test.name, func(t *testing.T) {
+			defer b.Reset()
+			log.InitJSONLogger(&log.Config{
+				Output:           b,
+				EnableStackTrace: true,
+				StackTraceLevel:  log.LogError,
+			})
+
+			e := log.WithFields(log.Fields{})
+			if test.err != nil {
+				e = e.WithError(test.err)
+			}
+			e.Error("stack")
+
+			var data struct {
+				Stacktrace []struct {
+					Function string `json:"function"`
+					File     string `json:"file"`
+					Line     int    `json:"line"`
+				} `json:"stacktrace"`
+			}
+			if err := json.Unmarshal([]byte(b.String()), &data); err != nil {
+				t.Fatalf("error unmarshalling buffer: %v", err)
+			}
+
+			if len(data.Stacktrace) == 0 {
+				t.Fatalf("expected stack trace: '%s'", b.String())
+			}
+
+			frame := data.Stacktrace[0]
+			if frame.Function != test.function || !strings.HasSuffix(frame.File, "stack_test.go") || frame.Line == 0 {
+				t.Errorf("unexpected first frame: %+v", frame)
+			}
+		})
+	}
+}
+
+func Test_EmptyErrorStack(t *testing.T) {
+	defer b.Reset()
+
+	inits := map[string]func(*log.Config){
+		"Simple": log.InitSimpleLogger,
+		"JSON":   log.InitJSONLogger,
+		"Logfmt": log.InitLogfmtLogger,
+	}
+
+	for name, init := range inits {
+		t.Run(name, func(t *testing.T) {
+			defer b.Reset()
+			init(&log.Config{
+				Output:           b,
+				EnableStackTrace: true,
+				StackTraceLevel:  log.LogError,
+			})
+
+			log.WithError(&callersError{pcs: []uintptr{}}).Error("empty stack")
+
+			// the stack of the caller is used instead
+			if !strings.Contains(b.String(), "Test_EmptyErrorStack") {
+				t.Errorf("expected the stack of the caller: '%s'", b.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package log
+
+import (
+	"strconv"
+	"time"
+)
+
+// Special values for Config.TimeFormat that print the timestamp as an integer
+// offset from the Unix epoch instead of using a time layout.
+const (
+	TimeFormatUnix      = "unix"
+	TimeFormatUnixMilli = "unixmilli"
+	TimeFormatUnixNano  = "unixnano"
+)
+
+// localTime converts t to the configured location, if any.
+func (c *Config) localTime(t time.Time) time.Time {
+	if c.TimeLocation != nil {
+		return t.In(c.TimeLocation)
+	}
+	return t
+}
+
+// unixTime returns t as an offset from the Unix epoch if one of the Unix
+// time formats is configured.
+func (c *Config) unixTime(t time.Time) (int64, bool) {
+	switch c.TimeFormat {
+	case TimeFormatUnix:
+		return t.Unix(), true
+	case TimeFormatUnixMilli:
+		return t.UnixMilli(), true
+	case TimeFormatUnixNano:
+		return t.UnixNano(), true
+	}
+	return 0, false
+}
+
+// appendTime appends t formatted according to the configured format, falling
+// back to the formatter specific defaultLayout.
+func (c *Config) appendTime(b []byte, t time.Time, defaultLayout string) []byte {
+	if unix, ok := c.unixTime(t); ok {
+		return strconv.AppendInt(b, unix, 10)
+	}
+
+	layout := c.TimeFormat
+	if layout == "" {
+		layout = defaultLayout
+	}
+	return c.localTime(t).AppendFormat(b, layout)
+}
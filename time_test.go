@@ -0,0 +1,135 @@
+package log_test
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Strum355/log"
+)
+
+func Test_TimeFormat(t *testing.T) {
+	defer b.Reset()
+
+	tests := []struct {
+		name   string
+		init   func(*log.Config)
+		conf   log.Config
+		regex  *regexp.Regexp
+		absent bool
+	}{
+		{
+			name:  "SimpleRFC3339Nano",
+			init:  log.InitSimpleLogger,
+			conf:  log.Config{TimeFormat: time.RFC3339Nano, TimeLocation: time.UTC},
+			regex: regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?Z \[`),
+		},
+		{
+			name:  "SimpleUnix",
+			init:  log.InitSimpleLogger,
+			conf:  log.Config{TimeFormat: log.TimeFormatUnix},
+			regex: regexp.MustCompile(`^\d{10} \[`),
+		},
+		{
+			name:  "SimpleDisabled",
+			init:  log.InitSimpleLogger,
+			conf:  log.Config{DisableTime: true},
+			regex: regexp.MustCompile(`^\[INFO \] `),
+		},
+		{
+			name:  "LogfmtUnixMilli",
+			init:  log.InitLogfmtLogger,
+			conf:  log.Config{TimeFormat: log.TimeFormatUnixMilli},
+			regex: regexp.MustCompile(`^time=\d{13} level=`),
+		},
+		{
+			name:  "LogfmtCustomLocation",
+			init:  log.InitLogfmtLogger,
+			conf:  log.Config{TimeFormat: "15:04 MST", TimeLocation: time.FixedZone("TEST", 3600)},
+			regex: regexp.MustCompile(`^time="\d{2}:\d{2} TEST" level=`),
+		},
+		{
+			name:  "LogfmtDisabled",
+			init:  log.InitLogfmtLogger,
+			conf:  log.Config{DisableTime: true},
+			regex: regexp.MustCompile(`^level=INFO `),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer b.Reset()
+			conf := test.conf
+			conf.Output = b
+			test.init(&conf)
+
+			log.Info("hello")
+
+			if !test.regex.MatchString(b.String()) {
+				t.Errorf("expected '%s' to match '%s'", b.String(), test.regex)
+			}
+		})
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			conf     log.Config
+			expected func(interface{}) bool
+		}{
+			{
+				name: "UnixNano",
+				conf: log.Config{TimeFormat: log.TimeFormatUnixNano},
+				expected: func(v interface{}) bool {
+					f, ok := v.(float64)
+					return ok && f > 1e18
+				},
+			},
+			{
+				name: "UTC",
+				conf: log.Config{TimeLocation: time.UTC},
+				expected: func(v interface{}) bool {
+					s, ok := v.(string)
+					return ok && strings.HasSuffix(s, "Z")
+				},
+			},
+			{
+				name: "Custom",
+				conf: log.Config{TimeFormat: "2006"},
+				expected: func(v interface{}) bool {
+					s, ok := v.(string)
+					return ok && len(s) == 4
+				},
+			},
+			{
+				name: "Disabled",
+				conf: log.Config{DisableTime: true},
+				expected: func(v interface{}) bool {
+					return v == nil
+				},
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				defer b.Reset()
+				conf := test.conf
+				conf.Output = b
+				log.InitJSONLogger(&conf)
+
+				log.Info("hello")
+
+				var data map[string]interface{}
+				if err := json.Unmarshal([]byte(b.String()), &data); err != nil {
+					t.Fatalf("error unmarshalling buffer: %v", err)
+				}
+
+				if !test.expected(data["time"]) {
+					t.Errorf("unexpected time value: %T '%v'", data["time"], data["time"])
+				}
+			})
+		}
+	})
+}
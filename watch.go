@@ -0,0 +1,289 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Watcher reloads the configuration of a logger from a file whenever the file
+// changes, see WatchConfig.
+type Watcher struct {
+	path     string
+	interval time.Duration
+	// store replaces and retires the configuration
+	store func(*Config)
+	entry *Entry
+
+	current *fileConfig
+	modTime time.Time
+	size    int64
+	// missing is true while the file can't be found
+	missing bool
+	// files are the files opened as outputs by path, reused across reloads
+	files map[string]*watchedFile
+	// used are the files used by the configuration being loaded
+	used []*watchedFile
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// WatchConfig configures the global logger from the file at path, see
+// ConfigFromFile, and polls it every interval for changes. Changes are
+// applied atomically, log points being written while the configuration is
+// replaced are written with either the old or the new configuration. Every
+// reload is logged along with the settings that changed. If the file becomes
+// invalid, the error is logged and the previous configuration is kept.
+func WatchConfig(path string, interval time.Duration) (*Watcher, error) {
+	return watchConfig(path, interval, storeConfig, emptyEntry)
+}
+
+// WatchConfig configures l from the file at path and polls it every interval
+// for changes, see the WatchConfig function.
+func (l *Logger) WatchConfig(path string, interval time.Duration) (*Watcher, error) {
+	return watchConfig(path, interval, func(c *Config) { l.config.Swap(c).retire() }, l.root)
+}
+
+func watchConfig(path string, interval time.Duration, store func(*Config), entry *Entry) (*Watcher, error) {
+	w := &Watcher{
+		path:     path,
+		interval: interval,
+		store:    store,
+		entry:    entry.withFixedCaller(),
+		files:    make(map[string]*watchedFile),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.load(info); err != nil {
+		w.releaseUnused()
+		return nil, err
+	}
+
+	go w.watch()
+	return w, nil
+}
+
+// Close stops watching the file. The outputs opened for the configuration
+// stay open until it is replaced, as the logger still writes to them.
+func (w *Watcher) Close() {
+	close(w.stop)
+	<-w.done
+
+	for path, f := range w.files {
+		f.release()
+		delete(w.files, path)
+	}
+}
+
+func (w *Watcher) watch() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			w.poll()
+		}
+	}
+}
+
+// poll reloads the configuration if the file was modified.
+func (w *Watcher) poll() {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		// only logged once, the file is usually replaced shortly after
+		if !w.missing {
+			w.entry.With(String("path", w.path), Err(err)).Error("failed to reload logging configuration")
+		}
+		w.missing = true
+		return
+	}
+
+	if info.ModTime().Equal(w.modTime) && info.Size() == w.size && !w.missing {
+		return
+	}
+	w.missing = false
+
+	previous := w.current
+	if err := w.load(info); err != nil {
+		// not retried until the file changes again
+		w.entry.With(String("path", w.path), Err(err)).Error("failed to reload logging configuration")
+		w.releaseUnused()
+		return
+	}
+
+	if changes := previous.changes(w.current); len(changes) > 0 {
+		w.entry.With(append(changes, String("path", w.path))...).Info("reloaded logging configuration")
+	}
+
+	// files that are no longer used are closed once the configurations
+	// referencing them are retired and done writing to them
+	w.releaseUnused()
+}
+
+// load reads the file and stores the configuration it describes.
+func (w *Watcher) load(info os.FileInfo) error {
+	w.modTime, w.size = info.ModTime(), info.Size()
+
+	fc, err := readConfigFile(w.path)
+	if err != nil {
+		return err
+	}
+
+	// files opened for an invalid configuration are released by
+	// releaseUnused, as open returns files used by the current configuration
+	w.used = w.used[:0]
+	conf, err := fc.config(w.open, func(io.Writer) {})
+	if err == nil {
+		conf, err = newLoggerConfig(conf)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", w.path, err)
+	}
+
+	conf.writes.files = append([]*watchedFile(nil), w.used...)
+	for _, f := range conf.writes.files {
+		f.acquire()
+	}
+
+	w.store(conf)
+	w.current = fc
+	return nil
+}
+
+// open opens the output at path, reusing files that are already open.
+func (w *Watcher) open(path string) (io.Writer, error) {
+	if f, ok := w.files[path]; ok {
+		w.used = append(w.used, f)
+		return f.File, nil
+	}
+
+	out, err := openOutput(path)
+	if err != nil {
+		return nil, err
+	}
+	if f, ok := out.(*os.File); ok && f != os.Stdout && f != os.Stderr {
+		// the reference of the watcher, released by releaseUnused
+		wf := &watchedFile{File: f}
+		wf.acquire()
+		w.files[path] = wf
+		w.used = append(w.used, wf)
+	}
+	return out, nil
+}
+
+// releaseUnused releases the files that aren't outputs of the current
+// configuration, which are closed once no configuration uses them.
+func (w *Watcher) releaseUnused() {
+	used := make(map[string]bool)
+	if w.current != nil {
+		used[w.current.Output] = true
+		for _, sink := range w.current.Sinks {
+			used[sink.Output] = true
+		}
+	}
+
+	for path, f := range w.files {
+		if !used[path] {
+			f.release()
+			delete(w.files, path)
+		}
+	}
+}
+
+// watchedFile is a file opened as an output by a Watcher. It is referenced by
+// the watcher while it may be reused and by every configuration using it,
+// and closed once all of them released it.
+type watchedFile struct {
+	*os.File
+	refs atomic.Int32
+}
+
+func (f *watchedFile) acquire() {
+	f.refs.Add(1)
+}
+
+func (f *watchedFile) release() {
+	if f.refs.Add(-1) == 0 {
+		f.Close()
+	}
+}
+
+// changes returns a field for every setting that differs between fc and
+// next, holding the old and new value. The keys are prefixed with "log_", so
+// they don't collide with the keys of the loggers, e.g. "level".
+func (fc *fileConfig) changes(next *fileConfig) []Field {
+	before, after := fc.settings(), next.settings()
+
+	keys := make([]string, 0, len(after))
+	for k := range after {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var changes []Field
+	for _, k := range keys {
+		if before[k] != after[k] {
+			changes = append(changes, String("log_"+k, before[k]+" -> "+after[k]))
+		}
+	}
+	return changes
+}
+
+// settings returns the settings of fc formatted as text.
+func (fc *fileConfig) settings() map[string]string {
+	text := func(m interface{ MarshalText() ([]byte, error) }) string {
+		b, _ := m.MarshalText()
+		return string(b)
+	}
+
+	output := fc.Output
+	if output == "" {
+		output = "stdout"
+	}
+
+	packages := make([]string, 0, len(fc.Packages))
+	for pkg, level := range fc.Packages {
+		packages = append(packages, pkg+"="+text(level))
+	}
+	sort.Strings(packages)
+
+	sinks := make([]string, 0, len(fc.Sinks))
+	for _, sink := range fc.Sinks {
+		sinks = append(sinks, sink.Output+" "+text(sink.Format)+" "+text(sink.Level))
+	}
+
+	sampling := "off"
+	if s := fc.Sampling; s != nil {
+		sampling = "initial=" + strconv.Itoa(s.Initial) + ",thereafter=" + strconv.Itoa(s.Thereafter) + ",tick=" + text(s.Tick)
+	}
+
+	return map[string]string{
+		"level":       text(fc.Level),
+		"format":      text(fc.Format),
+		"output":      output,
+		"caller":      text(fc.Caller),
+		"time_format": strconv.Quote(fc.TimeFormat),
+		"color":       strconv.FormatBool(fc.Color),
+		"stderr":      strconv.FormatBool(fc.StdErr),
+		"filter":      strconv.Quote(fc.Filter),
+		"packages":    "[" + strings.Join(packages, ",") + "]",
+		"sinks":       "[" + strings.Join(sinks, ", ") + "]",
+		"sampling":    sampling,
+	}
+}
@@ -0,0 +1,168 @@
+package log_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Strum355/log"
+)
+
+func Test_WatchConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "log.yaml")
+	output := filepath.Join(dir, "out.log")
+
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content+"\noutput: "+output+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	read := func() string {
+		t.Helper()
+		out, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(out)
+	}
+
+	waitFor := func(s string) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+			if strings.Contains(read(), s) {
+				return
+			}
+		}
+		t.Fatalf("expected '%s' in output: '%s'", s, read())
+	}
+
+	logger, err := log.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write("level: info\nformat: logfmt")
+	w, err := logger.WatchConfig(path, 5*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	logger.Debug("first debug")
+	logger.Info("first info")
+
+	write("level: debug\nformat: logfmt\npackages:\n  github.com/org/db: error")
+	waitFor(`caller=watch_test.go:51:Test_WatchConfig() msg="reloaded logging configuration" log_level="info -> debug" log_packages="[] -> [github.com/org/db=error]"`)
+
+	logger.Debug("second debug")
+
+	write("level: verbose")
+	waitFor(`msg="failed to reload logging configuration"`)
+
+	logger.Debug("third debug")
+
+	out := read()
+	if strings.Contains(out, "first debug") || !strings.Contains(out, "first info") {
+		t.Errorf("expected initial configuration to be applied: '%s'", out)
+	}
+
+	if !strings.Contains(out, "second debug") || !strings.Contains(out, "third debug") {
+		t.Errorf("expected reloaded configuration to be applied and kept: '%s'", out)
+	}
+
+	if !strings.Contains(out, `error="`+path+`: invalid log level \"verbose\"`) {
+		t.Errorf("expected reload error to be logged: '%s'", out)
+	}
+
+	write("level: info\nformat: json\nsampling:\n  initial: 1\n  tick: 1h")
+	waitFor(`"message":"reloaded logging configuration"`)
+
+	lines := strings.Split(strings.TrimSpace(read()), "\n")
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &data); err != nil {
+		t.Fatalf("error unmarshalling reload log point: %v", err)
+	}
+	if data["level"] != "INFO" || data["log_level"] != "debug -> info" || data["log_format"] != "logfmt -> json" ||
+		data["log_sampling"] != "off -> initial=1,thereafter=0,tick=1h0m0s" {
+		t.Errorf("expected level and changed settings in reload log point: %v", data)
+	}
+
+	for i := 0; i < 3; i++ {
+		logger.Info("sampled")
+	}
+	if count := strings.Count(read(), `"message":"sampled"`); count != 1 {
+		t.Errorf("expected reloaded sampling to be applied, got %d log points", count)
+	}
+}
+
+func Test_WatchConfigErrors(t *testing.T) {
+	logger, err := log.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "log.json")
+	if _, err := logger.WatchConfig(path, time.Second); !os.IsNotExist(err) {
+		t.Errorf("expected missing file error: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"stderr": true, "output": "stderr"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := logger.WatchConfig(path, time.Second); err == nil {
+		t.Error("expected invalid configuration error")
+	}
+}
+
+func Test_WatchConfigSetDefault(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "log.yaml")
+	first, second := filepath.Join(dir, "first.log"), filepath.Join(dir, "second.log")
+
+	logger, err := log.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte("output: "+first+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	w, err := logger.WatchConfig(path, 5*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	log.SetDefault(logger)
+
+	if err := os.WriteFile(path, []byte("output: "+second+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(5 * time.Millisecond) {
+		if out, _ := os.ReadFile(second); strings.Contains(string(out), "reloaded logging configuration") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected configuration to be reloaded")
+		}
+	}
+	// give the watcher time to close the file if it was released
+	time.Sleep(50 * time.Millisecond)
+
+	log.Info("global")
+	logger.Info("reloaded")
+
+	out, err := os.ReadFile(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "global") || strings.Contains(string(out), "reloaded\n") {
+		t.Errorf("expected the global logger to keep writing to the previous output: '%s'", out)
+	}
+}